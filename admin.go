@@ -0,0 +1,293 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClientInfo is a connection of CLIENT LIST
+type ClientInfo struct {
+	ID        int64
+	Addr      string
+	LocalAddr string
+	Name      string
+	User      string
+	Age       time.Duration
+	Idle      time.Duration
+	Flags     string
+	DB        int
+	// Cmd is the last command, "client|list" style for subcommands since redis 7
+	Cmd string
+	// Memory is the total memory of the connection, tot-mem, since redis 6
+	Memory int64
+	// OutputMemory is the memory of the output list, omem
+	OutputMemory int64
+	// Extra are the fields not above
+	Extra map[string]string
+}
+
+// SlotRange of cluster hash slots, End included
+type SlotRange struct {
+	Start int
+	End   int
+}
+
+// SlotMigration of a slot migrating to or importing from Node
+type SlotMigration struct {
+	Slot int
+	Node string
+}
+
+// ClusterNode is a node of CLUSTER NODES
+type ClusterNode struct {
+	ID   string
+	Addr string
+	// BusPort is the cluster bus port, 0 before redis 4
+	BusPort int
+	// Hostname since redis 7, empty if not announced
+	Hostname string
+	// Aux are the key=value fields of the address since redis 7.2, e.g. shard-id
+	Aux   map[string]string
+	Flags []string
+	// Master id of a replica
+	Master      string
+	PingSent    int64
+	PongRecv    int64
+	ConfigEpoch int64
+	LinkState   string
+	Slots       []SlotRange
+	Migrating   []SlotMigration
+	Importing   []SlotMigration
+}
+
+// HasFlag reports whether the node has flag, e.g. "myself", "master", "fail?"
+func (n *ClusterNode) HasFlag(flag string) bool {
+	for _, f := range n.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsedClientList returns the connections of CLIENT LIST
+func (c Client) ParsedClientList() ([]ClientInfo, error) {
+	s, err := c.ClientList().Result()
+	if err != nil {
+		return nil, err
+	}
+	return ParseClientList(s)
+}
+
+// ParseClientList parses the output of CLIENT LIST
+func ParseClientList(s string) ([]ClientInfo, error) {
+	var clients []ClientInfo
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		client, err := parseClientInfo(line)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+func parseClientInfo(line string) (ClientInfo, error) {
+	var client ClientInfo
+	for _, field := range strings.Fields(line) {
+		i := strings.IndexByte(field, '=')
+		if i < 0 {
+			return client, errors.New("redis: malformed client list field " + field)
+		}
+		name, value := field[:i], field[i+1:]
+		var err error
+		switch name {
+		case "id":
+			client.ID, err = strconv.ParseInt(value, 10, 64)
+		case "addr":
+			client.Addr = value
+		case "laddr":
+			client.LocalAddr = value
+		case "name":
+			client.Name = value
+		case "user":
+			client.User = value
+		case "age":
+			client.Age, err = parseSeconds(value)
+		case "idle":
+			client.Idle, err = parseSeconds(value)
+		case "flags":
+			client.Flags = value
+		case "db":
+			client.DB, err = strconv.Atoi(value)
+		case "cmd":
+			client.Cmd = value
+		case "tot-mem":
+			client.Memory, err = strconv.ParseInt(value, 10, 64)
+		case "omem":
+			client.OutputMemory, err = strconv.ParseInt(value, 10, 64)
+		default:
+			if client.Extra == nil {
+				client.Extra = make(map[string]string)
+			}
+			client.Extra[name] = value
+		}
+		if err != nil {
+			return client, errors.New("redis: malformed client list field " + field)
+		}
+	}
+	return client, nil
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	return time.Duration(n) * time.Second, err
+}
+
+// ParsedClusterNodes returns the nodes of CLUSTER NODES
+func (c Client) ParsedClusterNodes() ([]ClusterNode, error) {
+	s, err := c.ClusterNodes().Result()
+	if err != nil {
+		return nil, err
+	}
+	return ParseClusterNodes(s)
+}
+
+// ParseClusterNodes parses the output of CLUSTER NODES
+func ParseClusterNodes(s string) ([]ClusterNode, error) {
+	var nodes []ClusterNode
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		node, err := parseClusterNode(line)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func parseClusterNode(line string) (ClusterNode, error) {
+	var node ClusterNode
+	fields := strings.Fields(line)
+	if len(fields) < 8 {
+		return node, errors.New("redis: malformed cluster nodes line " + line)
+	}
+	node.ID = fields[0]
+
+	// ip:port@cport[,hostname[,key=value...]]
+	parts := strings.Split(fields[1], ",")
+	addr := parts[0]
+	for i, part := range parts[1:] {
+		j := strings.IndexByte(part, '=')
+		if i == 0 && j < 0 {
+			node.Hostname = part
+			continue
+		}
+		if j < 0 {
+			return node, errors.New("redis: malformed cluster nodes address " + fields[1])
+		}
+		if node.Aux == nil {
+			node.Aux = make(map[string]string)
+		}
+		node.Aux[part[:j]] = part[j+1:]
+	}
+	if i := strings.IndexByte(addr, '@'); i >= 0 {
+		port, err := strconv.Atoi(addr[i+1:])
+		if err != nil {
+			return node, errors.New("redis: malformed cluster nodes address " + fields[1])
+		}
+		addr, node.BusPort = addr[:i], port
+	}
+	node.Addr = addr
+
+	if fields[2] != "noflags" {
+		node.Flags = strings.Split(fields[2], ",")
+	}
+	if fields[3] != "-" {
+		node.Master = fields[3]
+	}
+	var err error
+	if node.PingSent, err = strconv.ParseInt(fields[4], 10, 64); err != nil {
+		return node, errors.New("redis: malformed cluster nodes line " + line)
+	}
+	if node.PongRecv, err = strconv.ParseInt(fields[5], 10, 64); err != nil {
+		return node, errors.New("redis: malformed cluster nodes line " + line)
+	}
+	if node.ConfigEpoch, err = strconv.ParseInt(fields[6], 10, 64); err != nil {
+		return node, errors.New("redis: malformed cluster nodes line " + line)
+	}
+	node.LinkState = fields[7]
+
+	for _, slot := range fields[8:] {
+		if err := node.addSlot(slot); err != nil {
+			return node, err
+		}
+	}
+	return node, nil
+}
+
+// addSlot parses a slot, a range start-end, [slot->-node] or [slot-<-node]
+func (n *ClusterNode) addSlot(s string) error {
+	malformed := errors.New("redis: malformed cluster nodes slot " + s)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s := s[1 : len(s)-1]
+		migrating := true
+		i := strings.Index(s, "->-")
+		if i < 0 {
+			migrating = false
+			i = strings.Index(s, "-<-")
+		}
+		if i < 0 {
+			return malformed
+		}
+		slot, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return malformed
+		}
+		m := SlotMigration{Slot: slot, Node: s[i+3:]}
+		if migrating {
+			n.Migrating = append(n.Migrating, m)
+		} else {
+			n.Importing = append(n.Importing, m)
+		}
+		return nil
+	}
+
+	start, end := s, s
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		start, end = s[:i], s[i+1:]
+	}
+	var r SlotRange
+	var err error
+	if r.Start, err = strconv.Atoi(start); err != nil {
+		return malformed
+	}
+	if r.End, err = strconv.Atoi(end); err != nil {
+		return malformed
+	}
+	n.Slots = append(n.Slots, r)
+	return nil
+}
+
+// ConfigGetMap returns the parameters matching the glob pattern parameter
+func (c Client) ConfigGetMap(parameter string) (map[string]string, error) {
+	vals, err := c.ConfigGet(parameter).Result()
+	if err != nil {
+		return nil, err
+	}
+	config := make(map[string]string, len(vals)/2)
+	for i := 0; i+1 < len(vals); i += 2 {
+		config[argString(vals[i])] = argString(vals[i+1])
+	}
+	return config, nil
+}
@@ -0,0 +1,243 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultAutoPipelineMaxBatch = 100
+	defaultAutoPipelineWindow   = 100 * time.Microsecond
+)
+
+// errAutoPipelined stops the regular processing of a command already executed in a batch
+var errAutoPipelined = errors.New("redis: command executed by auto pipeline")
+
+// AutoPipelineOptions for batching concurrent commands into pipelines.
+//
+// A batched command runs through the hooks after the auto pipeline as part of
+// the pipeline of its batch, so the tracing hook records the batch as one
+// pipeline span, not a span per command. The embedded (*redis.Client).Process
+// returns an internal error for a batched command even when it succeeded, use
+// Client.Process or the Err of the command. Bind a ctx from WithoutAutoPipeline
+// to run commands one by one through every hook.
+type AutoPipelineOptions struct {
+	// MaxBatch flushes a batch once it holds this many commands, default 100
+	MaxBatch int `yaml:"max_batch"`
+	// Window flushes a batch this long after its first command, default 100µs
+	Window time.Duration `yaml:"window"`
+}
+
+// autoPipelineSkip are the commands never batched, they block or change the connection state
+var autoPipelineSkip = map[string]bool{
+	"blpop": true, "brpop": true, "brpoplpush": true, "blmove": true,
+	"bzpopmin": true, "bzpopmax": true, "wait": true,
+	"multi": true, "exec": true, "discard": true, "watch": true, "unwatch": true,
+	"subscribe": true, "psubscribe": true, "unsubscribe": true, "punsubscribe": true,
+	"monitor": true, "quit": true, "select": true, "auth": true, "hello": true,
+	"client": true, "readonly": true, "readwrite": true, "shutdown": true,
+}
+
+type autoPipelineBatch struct {
+	cmds []redis.Cmder
+	done chan struct{}
+	// values is the ctx of the first caller, its values are passed to the batch
+	values context.Context
+	// deadline is the latest deadline of the callers, none if one of them has none
+	deadline   time.Time
+	noDeadline bool
+}
+
+// valuesContext has the values of another ctx without its deadline and cancellation
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c valuesContext) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
+
+type autoPipelineResult struct {
+	err error
+}
+
+type autoPipelineKey struct{}
+
+type autoPipelineBatchKey struct{}
+
+type autoPipelineSkipKey struct{}
+
+// WithoutAutoPipeline returns a ctx whose commands are never batched by the auto pipeline
+func WithoutAutoPipeline(ctx context.Context) context.Context {
+	return context.WithValue(ctx, autoPipelineSkipKey{}, true)
+}
+
+// isAutoPipelineBatch reports whether ctx is the ctx of a batch, its commands were
+// already checked by the hooks before the auto pipeline with the ctx of their caller
+func isAutoPipelineBatch(ctx context.Context) bool {
+	return ctx.Value(autoPipelineBatchKey{}) != nil
+}
+
+// autoPipelineHook executes commands issued concurrently in shared pipelines,
+// only hooks which are safe to run twice may come before it, the batch runs
+// through every hook as a pipeline
+type autoPipelineHook struct {
+	client *redis.Client
+	opt    AutoPipelineOptions
+
+	mu    sync.Mutex
+	batch *autoPipelineBatch
+}
+
+func newAutoPipelineHook(client *redis.Client, opt AutoPipelineOptions) *autoPipelineHook {
+	if opt.MaxBatch <= 0 {
+		opt.MaxBatch = defaultAutoPipelineMaxBatch
+	}
+	if opt.Window <= 0 {
+		opt.Window = defaultAutoPipelineWindow
+	}
+	return &autoPipelineHook{client: client, opt: opt}
+}
+
+func autoPipelined(cmd redis.Cmder) bool {
+	name := cmd.Name()
+	if autoPipelineSkip[name] {
+		return false
+	}
+	if name == "xread" || name == "xreadgroup" {
+		for _, arg := range cmd.Args() {
+			if argEqual(arg, "block") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (h *autoPipelineHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if !autoPipelined(cmd) || ctx.Value(autoPipelineSkipKey{}) != nil {
+		return ctx, nil
+	}
+	// the caller waits for the whole batch, cmd is shared with the pipeline until then
+	batch := h.enqueue(ctx, cmd)
+	res := &autoPipelineResult{}
+	select {
+	case <-batch.done:
+		res.err = cmd.Err()
+	case <-ctx.Done():
+		if h.remove(batch, cmd) {
+			res.err = ctx.Err()
+			break
+		}
+		// already sent, cmd is written until the batch is done, bounded by the latest deadline of the callers
+		<-batch.done
+		res.err = cmd.Err()
+	}
+	return context.WithValue(ctx, autoPipelineKey{}, res), errAutoPipelined
+}
+
+func (h *autoPipelineHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if res, ok := ctx.Value(autoPipelineKey{}).(*autoPipelineResult); ok {
+		cmd.SetErr(res.err)
+	}
+	return nil
+}
+
+func (h *autoPipelineHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *autoPipelineHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+// Process executes cmd and returns its error, also when the auto pipeline batched it
+func (c Client) Process(ctx context.Context, cmd redis.Cmder) error {
+	err := c.Client.Process(ctx, cmd)
+	if err == errAutoPipelined {
+		return cmd.Err()
+	}
+	return err
+}
+
+func (h *autoPipelineHook) enqueue(ctx context.Context, cmd redis.Cmder) *autoPipelineBatch {
+	h.mu.Lock()
+	batch := h.batch
+	if batch == nil {
+		batch = &autoPipelineBatch{done: make(chan struct{}), values: ctx}
+		h.batch = batch
+		time.AfterFunc(h.opt.Window, func() {
+			h.flush(batch)
+		})
+	}
+	batch.cmds = append(batch.cmds, cmd)
+	if deadline, ok := ctx.Deadline(); !ok {
+		batch.noDeadline = true
+	} else if deadline.After(batch.deadline) {
+		batch.deadline = deadline
+	}
+	full := len(batch.cmds) >= h.opt.MaxBatch
+	if full {
+		h.batch = nil
+	}
+	h.mu.Unlock()
+
+	if full {
+		h.exec(batch)
+	}
+	return batch
+}
+
+// remove takes cmd out of batch, unless the batch was already sent
+func (h *autoPipelineHook) remove(batch *autoPipelineBatch, cmd redis.Cmder) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.batch != batch {
+		return false
+	}
+	for i, c := range batch.cmds {
+		if c == cmd {
+			batch.cmds = append(batch.cmds[:i], batch.cmds[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// flush executes batch when its window elapsed, unless it was already executed because it was full
+func (h *autoPipelineHook) flush(batch *autoPipelineBatch) {
+	h.mu.Lock()
+	if h.batch != batch {
+		h.mu.Unlock()
+		return
+	}
+	h.batch = nil
+	h.mu.Unlock()
+	h.exec(batch)
+}
+
+func (h *autoPipelineHook) exec(batch *autoPipelineBatch) {
+	defer close(batch.done)
+	if len(batch.cmds) == 0 {
+		return
+	}
+	// the batch must not fail because one of its callers gave up
+	var ctx context.Context = valuesContext{Context: context.Background(), values: batch.values}
+	ctx = context.WithValue(ctx, autoPipelineBatchKey{}, true)
+	if !batch.noDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, batch.deadline)
+		defer cancel()
+	}
+	_, _ = h.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, cmd := range batch.cmds {
+			_ = pipe.Process(ctx, cmd)
+		}
+		return nil
+	})
+}
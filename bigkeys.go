@@ -0,0 +1,234 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultBigKeysTopN      = 10
+	defaultBigKeysDelimiter = ":"
+	defaultBigKeysScanCount = 100
+)
+
+// BigKeysOptions for the big-key analyzer
+type BigKeysOptions struct {
+	// Match pattern of the keys, default all
+	Match string
+	// ScanCount hint per Scan page, default 100
+	ScanCount int64
+	// TopN keys reported per ranking, default 10
+	TopN int
+	// Delimiter of key prefixes, default ":"
+	Delimiter string
+	// PrefixDepth is the number of delimited parts of a prefix, default 1
+	PrefixDepth int
+	// Samples of MemoryUsage for nested values, 0 for the server default
+	Samples int
+	// Interval to wait between Scan pages, keeps the scan gentle on the server
+	Interval time.Duration
+}
+
+// BigKey found by the analyzer, Length is the number of elements,
+// or the number of bytes for strings
+type BigKey struct {
+	Key    string
+	Type   string
+	Memory int64
+	Length int64
+}
+
+// KeyGroupStats are the aggregate sizes of a group of keys
+type KeyGroupStats struct {
+	Keys   int64
+	Memory int64
+	Length int64
+}
+
+// BigKeysReport of the big-key analyzer
+type BigKeysReport struct {
+	Keys int64
+	// TopByMemory keys of any type
+	TopByMemory []BigKey
+	// TopByLength keys per type
+	TopByLength map[string][]BigKey
+	// Types are the sizes per type
+	Types map[string]*KeyGroupStats
+	// Prefixes are the sizes per key prefix
+	Prefixes map[string]*KeyGroupStats
+}
+
+// SortedPrefixes returns the prefixes ordered by memory, largest first
+func (r *BigKeysReport) SortedPrefixes() []string {
+	prefixes := make([]string, 0, len(r.Prefixes))
+	for prefix := range r.Prefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return r.Prefixes[prefixes[i]].Memory > r.Prefixes[prefixes[j]].Memory
+	})
+	return prefixes
+}
+
+// BigKeys walks the keyspace like redis-cli --bigkeys --memkeys, sampling the
+// type, memory usage and length of every key
+func (c Client) BigKeys(opt BigKeysOptions) (*BigKeysReport, error) {
+	if opt.TopN <= 0 {
+		opt.TopN = defaultBigKeysTopN
+	}
+	if opt.Delimiter == "" {
+		opt.Delimiter = defaultBigKeysDelimiter
+	}
+	if opt.PrefixDepth <= 0 {
+		opt.PrefixDepth = 1
+	}
+	if opt.ScanCount <= 0 {
+		opt.ScanCount = defaultBigKeysScanCount
+	}
+	report := &BigKeysReport{
+		TopByLength: make(map[string][]BigKey),
+		Types:       make(map[string]*KeyGroupStats),
+		Prefixes:    make(map[string]*KeyGroupStats),
+	}
+
+	first := true
+	err := c.ScanKeys(ScanOptions{Match: opt.Match, Count: opt.ScanCount}, func(keys []string) error {
+		if !first && opt.Interval > 0 {
+			sleepCtx(c.getCtx(), opt.Interval)
+			if err := c.getCtx().Err(); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		bigKeys, err := c.sampleKeys(keys, opt.Samples)
+		if err != nil {
+			return err
+		}
+		for _, k := range bigKeys {
+			report.add(k, opt)
+		}
+		return nil
+	})
+	return report, err
+}
+
+// sampleKeys returns the type, memory usage and length of keys, keys deleted
+// meanwhile are skipped
+func (c Client) sampleKeys(keys []string, samples int) ([]BigKey, error) {
+	ctx := c.getCtx()
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	_, err := c.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			typeCmds[i] = pipe.Type(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bigKeys := make([]BigKey, 0, len(keys))
+	for i, key := range keys {
+		if typ := typeCmds[i].Val(); typ != "none" {
+			bigKeys = append(bigKeys, BigKey{Key: key, Type: typ})
+		}
+	}
+	memCmds := make([]*redis.IntCmd, len(bigKeys))
+	lenCmds := make([]*redis.IntCmd, len(bigKeys))
+	_, err = c.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, k := range bigKeys {
+			if samples > 0 {
+				memCmds[i] = pipe.MemoryUsage(ctx, k.Key, samples)
+			} else {
+				memCmds[i] = pipe.MemoryUsage(ctx, k.Key)
+			}
+			lenCmds[i] = lengthCmd(ctx, pipe, k.Key, k.Type)
+		}
+		return nil
+	})
+	// keys deleted or replaced meanwhile fail with a reply error
+	if _, ok := err.(redis.Error); err != nil && !ok {
+		return nil, err
+	}
+	for i := range bigKeys {
+		bigKeys[i].Memory = memCmds[i].Val()
+		if lenCmds[i] != nil {
+			bigKeys[i].Length = lenCmds[i].Val()
+		}
+	}
+	return bigKeys, nil
+}
+
+// lengthCmd queues the length command of a key of type typ
+func lengthCmd(ctx context.Context, pipe redis.Pipeliner, key, typ string) *redis.IntCmd {
+	switch typ {
+	case "string":
+		return pipe.StrLen(ctx, key)
+	case "hash":
+		return pipe.HLen(ctx, key)
+	case "list":
+		return pipe.LLen(ctx, key)
+	case "set":
+		return pipe.SCard(ctx, key)
+	case "zset":
+		return pipe.ZCard(ctx, key)
+	case "stream":
+		return pipe.XLen(ctx, key)
+	}
+	return nil
+}
+
+func (r *BigKeysReport) add(k BigKey, opt BigKeysOptions) {
+	r.Keys++
+	addKeyGroup(r.Types, k.Type, k)
+	addKeyGroup(r.Prefixes, keyPrefix(k.Key, opt.Delimiter, opt.PrefixDepth), k)
+	r.TopByMemory = insertTop(r.TopByMemory, k, opt.TopN, func(a, b BigKey) bool { return a.Memory > b.Memory })
+	r.TopByLength[k.Type] = insertTop(r.TopByLength[k.Type], k, opt.TopN, func(a, b BigKey) bool { return a.Length > b.Length })
+}
+
+func addKeyGroup(groups map[string]*KeyGroupStats, name string, k BigKey) {
+	g, ok := groups[name]
+	if !ok {
+		g = &KeyGroupStats{}
+		groups[name] = g
+	}
+	g.Keys++
+	g.Memory += k.Memory
+	g.Length += k.Length
+}
+
+// insertTop inserts k into top ordered by less, keeping at most n keys
+func insertTop(top []BigKey, k BigKey, n int, less func(a, b BigKey) bool) []BigKey {
+	i := sort.Search(len(top), func(i int) bool { return less(k, top[i]) })
+	if i >= n {
+		return top
+	}
+	top = append(top, BigKey{})
+	copy(top[i+1:], top[i:])
+	top[i] = k
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// keyPrefix returns the first depth parts of key, keys without delimiter have no prefix
+func keyPrefix(key, delimiter string, depth int) string {
+	end := 0
+	for i := 0; i < depth; i++ {
+		j := strings.Index(key[end:], delimiter)
+		if j < 0 {
+			break
+		}
+		end += j + len(delimiter)
+	}
+	if end == 0 {
+		return ""
+	}
+	return key[:end]
+}
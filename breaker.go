@@ -0,0 +1,287 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultBreakerWindow                 = 10 * time.Second
+	defaultBreakerMinRequests            = 20
+	defaultBreakerErrorRate              = 0.5
+	defaultBreakerMaxConsecutiveTimeouts = 5
+	defaultBreakerOpenTimeout            = 5 * time.Second
+	defaultBreakerHalfOpenRequests       = 3
+)
+
+// BreakerState of the circuit breaker
+type BreakerState int
+
+// circuit breaker states
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerOpenError is returned without touching the network while the breaker is open
+type BreakerOpenError struct {
+	State BreakerState
+}
+
+func (e *BreakerOpenError) Error() string {
+	return "redis: circuit breaker is " + e.State.String()
+}
+
+// BreakerFallback may fill cmd with a fallback value, returning true clears
+// the BreakerOpenError of cmd
+type BreakerFallback func(ctx context.Context, cmd redis.Cmder) bool
+
+// BreakerOptions for circuit breaker
+type BreakerOptions struct {
+	// Window over which the error rate is computed, default 10s
+	Window time.Duration `yaml:"window"`
+	// MinRequests in Window before the error rate can trip the breaker, default 20
+	MinRequests int `yaml:"min_requests"`
+	// ErrorRate trips the breaker, default 0.5
+	ErrorRate float64 `yaml:"error_rate"`
+	// MaxConsecutiveTimeouts trips the breaker, default 5
+	MaxConsecutiveTimeouts int `yaml:"max_consecutive_timeouts"`
+	// OpenTimeout before the breaker lets trial requests through, default 5s
+	OpenTimeout time.Duration `yaml:"open_timeout"`
+	// HalfOpenRequests is the number of trial requests which must succeed to close the breaker, default 3
+	HalfOpenRequests int `yaml:"half_open_requests"`
+
+	// Fallback is called for every request rejected by the breaker, the commands it serves
+	// are counted in the breaker_fallback error class of the metrics
+	Fallback BreakerFallback `yaml:"-"`
+	// OnStateChange is called on every transition, e.g. for metrics
+	OnStateChange func(from, to BreakerState) `yaml:"-"`
+}
+
+// Breaker is a circuit breaker around the client, it's installed as a hook
+type Breaker struct {
+	opt BreakerOptions
+
+	mu                  sync.Mutex
+	state               BreakerState
+	windowStart         time.Time
+	requests            int
+	failures            int
+	consecutiveTimeouts int
+	openedAt            time.Time
+	trials              int
+	trialSuccesses      int
+}
+
+type breakerKey struct{}
+
+type breakerResult struct {
+	rejected bool
+	trial    bool
+}
+
+func newBreaker(opt BreakerOptions) *Breaker {
+	if opt.Window <= 0 {
+		opt.Window = defaultBreakerWindow
+	}
+	if opt.MinRequests <= 0 {
+		opt.MinRequests = defaultBreakerMinRequests
+	}
+	if opt.ErrorRate <= 0 {
+		opt.ErrorRate = defaultBreakerErrorRate
+	}
+	if opt.MaxConsecutiveTimeouts <= 0 {
+		opt.MaxConsecutiveTimeouts = defaultBreakerMaxConsecutiveTimeouts
+	}
+	if opt.OpenTimeout <= 0 {
+		opt.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if opt.HalfOpenRequests <= 0 {
+		opt.HalfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+	return &Breaker{opt: opt, windowStart: time.Now()}
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh(time.Now())
+	return b.state
+}
+
+// Stats returns the requests and failures of the current window
+func (b *Breaker) Stats() (requests, failures int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.requests, b.failures
+}
+
+// refresh moves from open to half-open once OpenTimeout elapsed, b.mu must be held
+func (b *Breaker) refresh(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.opt.OpenTimeout {
+		b.setState(BreakerHalfOpen)
+	}
+	if b.state == BreakerClosed && now.Sub(b.windowStart) >= b.opt.Window {
+		b.windowStart = now
+		b.requests = 0
+		b.failures = 0
+	}
+}
+
+// setState changes the state, b.mu must be held
+func (b *Breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	now := time.Now()
+	switch state {
+	case BreakerOpen:
+		b.openedAt = now
+	case BreakerHalfOpen:
+		b.trials = 0
+		b.trialSuccesses = 0
+	case BreakerClosed:
+		b.windowStart = now
+		b.requests = 0
+		b.failures = 0
+		b.consecutiveTimeouts = 0
+	}
+	if b.opt.OnStateChange != nil {
+		go b.opt.OnStateChange(from, state)
+	}
+}
+
+func (b *Breaker) allow() (trial bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh(time.Now())
+	switch b.state {
+	case BreakerOpen:
+		return false, &BreakerOpenError{State: BreakerOpen}
+	case BreakerHalfOpen:
+		if b.trials >= b.opt.HalfOpenRequests {
+			return false, &BreakerOpenError{State: BreakerHalfOpen}
+		}
+		b.trials++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (b *Breaker) done(trial bool, err error) {
+	class := errorClass(err)
+	failed, timeout := false, false
+	switch class {
+	case "timeout", "pool_timeout", "deadline":
+		failed, timeout = true, true
+	case "network", "other":
+		failed = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if trial {
+		if b.state != BreakerHalfOpen {
+			return
+		}
+		if failed {
+			b.setState(BreakerOpen)
+			return
+		}
+		b.trialSuccesses++
+		if b.trialSuccesses >= b.opt.HalfOpenRequests {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+	if b.state != BreakerClosed {
+		return
+	}
+
+	b.refresh(time.Now())
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if timeout {
+		b.consecutiveTimeouts++
+	} else {
+		b.consecutiveTimeouts = 0
+	}
+	if b.consecutiveTimeouts >= b.opt.MaxConsecutiveTimeouts ||
+		(b.requests >= b.opt.MinRequests && float64(b.failures)/float64(b.requests) >= b.opt.ErrorRate) {
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *Breaker) before(ctx context.Context) (context.Context, error) {
+	trial, err := b.allow()
+	return context.WithValue(ctx, breakerKey{}, breakerResult{rejected: err != nil, trial: trial}), err
+}
+
+func (b *Breaker) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return b.before(ctx)
+}
+
+func (b *Breaker) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	res, _ := ctx.Value(breakerKey{}).(breakerResult)
+	if res.rejected {
+		if b.opt.Fallback != nil && b.opt.Fallback(ctx, cmd) {
+			cmd.SetErr(nil)
+		}
+		return nil
+	}
+	b.done(res.trial, cmd.Err())
+	return nil
+}
+
+func (b *Breaker) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return b.before(ctx)
+}
+
+func (b *Breaker) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	res, _ := ctx.Value(breakerKey{}).(breakerResult)
+	if res.rejected {
+		if b.opt.Fallback != nil {
+			for _, cmd := range cmds {
+				if b.opt.Fallback(ctx, cmd) {
+					cmd.SetErr(nil)
+				}
+			}
+		}
+		return nil
+	}
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && errorClass(cmdErr) != "server" {
+			err = cmdErr
+			break
+		}
+	}
+	b.done(res.trial, err)
+	return nil
+}
+
+// Breaker returns the circuit breaker, nil if Config.Breaker is not set
+func (c Client) Breaker() *Breaker {
+	return c.breaker
+}
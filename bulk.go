@@ -0,0 +1,233 @@
+package redis
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultBulkChunkSize         = 500
+	defaultBulkChunksPerPipeline = 8
+	defaultBulkConcurrency       = 4
+
+	clusterSlots = 16384
+)
+
+// BulkOptions for chunked bulk commands
+type BulkOptions struct {
+	// ChunkSize is the max number of keys per command, default 500
+	ChunkSize int
+	// ChunksPerPipeline is the number of commands sent per pipeline, default 8
+	ChunksPerPipeline int
+	// Concurrency is the max number of pipelines in flight, default 4
+	Concurrency int
+	// GroupBySlot puts only keys of the same hash slot in a chunk, for redis cluster
+	GroupBySlot bool
+}
+
+// ChunkError of one chunk of a bulk command
+type ChunkError struct {
+	Keys []string
+	Err  error
+}
+
+// BulkError is returned when some chunks of a bulk command failed,
+// the results of the other chunks are still returned
+type BulkError struct {
+	Chunks []ChunkError
+}
+
+func (e *BulkError) Error() string {
+	return "redis: " + strconv.Itoa(len(e.Chunks)) + " bulk chunks failed, first: " + e.Chunks[0].Err.Error()
+}
+
+type bulkChunk struct {
+	// index of every key in the original key set
+	index []int
+	keys  []string
+}
+
+func (opt *BulkOptions) init() {
+	if opt.ChunkSize <= 0 {
+		opt.ChunkSize = defaultBulkChunkSize
+	}
+	if opt.ChunksPerPipeline <= 0 {
+		opt.ChunksPerPipeline = defaultBulkChunksPerPipeline
+	}
+	if opt.Concurrency <= 0 {
+		opt.Concurrency = defaultBulkConcurrency
+	}
+}
+
+// BulkMGet gets keys in chunks, the values are in the order of keys
+func (c Client) BulkMGet(keys []string, opt BulkOptions) ([]interface{}, error) {
+	vals := make([]interface{}, len(keys))
+	err := c.bulk(keys, opt, func(pipe redis.Pipeliner, chunk bulkChunk) redis.Cmder {
+		return pipe.MGet(c.getCtx(), chunk.keys...)
+	}, func(chunk bulkChunk, cmd redis.Cmder) {
+		for i, v := range cmd.(*redis.SliceCmd).Val() {
+			vals[chunk.index[i]] = v
+		}
+	})
+	return vals, err
+}
+
+// BulkMSet sets values in chunks
+func (c Client) BulkMSet(values map[string]interface{}, opt BulkOptions) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	return c.bulk(keys, opt, func(pipe redis.Pipeliner, chunk bulkChunk) redis.Cmder {
+		pairs := make([]interface{}, 0, 2*len(chunk.keys))
+		for _, key := range chunk.keys {
+			pairs = append(pairs, key, values[key])
+		}
+		return pipe.MSet(c.getCtx(), pairs...)
+	}, nil)
+}
+
+// BulkDel deletes keys in chunks and returns the number of keys deleted
+func (c Client) BulkDel(keys []string, opt BulkOptions) (int64, error) {
+	var mu sync.Mutex
+	var n int64
+	err := c.bulk(keys, opt, func(pipe redis.Pipeliner, chunk bulkChunk) redis.Cmder {
+		return pipe.Del(c.getCtx(), chunk.keys...)
+	}, func(chunk bulkChunk, cmd redis.Cmder) {
+		mu.Lock()
+		n += cmd.(*redis.IntCmd).Val()
+		mu.Unlock()
+	})
+	return n, err
+}
+
+// BulkUnlink unlinks keys in chunks and returns the number of keys unlinked
+func (c Client) BulkUnlink(keys []string, opt BulkOptions) (int64, error) {
+	var mu sync.Mutex
+	var n int64
+	err := c.bulk(keys, opt, func(pipe redis.Pipeliner, chunk bulkChunk) redis.Cmder {
+		return pipe.Unlink(c.getCtx(), chunk.keys...)
+	}, func(chunk bulkChunk, cmd redis.Cmder) {
+		mu.Lock()
+		n += cmd.(*redis.IntCmd).Val()
+		mu.Unlock()
+	})
+	return n, err
+}
+
+// bulk splits keys into chunks, queues one command per chunk with add and
+// passes the successful ones to done
+func (c Client) bulk(keys []string, opt BulkOptions,
+	add func(redis.Pipeliner, bulkChunk) redis.Cmder, done func(bulkChunk, redis.Cmder)) error {
+	opt.init()
+	chunks := splitChunks(keys, opt.ChunkSize, opt.GroupBySlot)
+
+	var (
+		mu     sync.Mutex
+		failed []ChunkError
+		wg     sync.WaitGroup
+	)
+	sem := make(chan struct{}, opt.Concurrency)
+	for start := 0; start < len(chunks); start += opt.ChunksPerPipeline {
+		end := start + opt.ChunksPerPipeline
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+		batch := chunks[start:end]
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			cmds := make([]redis.Cmder, len(batch))
+			// errors are per command, the pipeline error is the first of them
+			_, _ = c.Pipelined(func(pipe redis.Pipeliner) error {
+				for i, chunk := range batch {
+					cmds[i] = add(pipe, chunk)
+				}
+				return nil
+			})
+			for i, cmd := range cmds {
+				if err := cmd.Err(); err != nil {
+					mu.Lock()
+					failed = append(failed, ChunkError{Keys: batch[i].keys, Err: err})
+					mu.Unlock()
+					continue
+				}
+				if done != nil {
+					done(batch[i], cmd)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return &BulkError{Chunks: failed}
+	}
+	return nil
+}
+
+func splitChunks(keys []string, size int, bySlot bool) []bulkChunk {
+	var chunks []bulkChunk
+	if !bySlot {
+		for start := 0; start < len(keys); start += size {
+			end := start + size
+			if end > len(keys) {
+				end = len(keys)
+			}
+			chunk := bulkChunk{index: make([]int, end-start), keys: keys[start:end]}
+			for i := range chunk.index {
+				chunk.index[i] = start + i
+			}
+			chunks = append(chunks, chunk)
+		}
+		return chunks
+	}
+
+	open := make(map[int]int)
+	for i, key := range keys {
+		slot := HashSlot(key)
+		ci, ok := open[slot]
+		if !ok || len(chunks[ci].keys) >= size {
+			chunks = append(chunks, bulkChunk{})
+			ci = len(chunks) - 1
+			open[slot] = ci
+		}
+		chunks[ci].index = append(chunks[ci].index, i)
+		chunks[ci].keys = append(chunks[ci].keys, key)
+	}
+	return chunks
+}
+
+// HashSlot returns the redis cluster hash slot of key, honoring {hash tags}
+func HashSlot(key string) int {
+	if s := strings.IndexByte(key, '{'); s >= 0 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+1+e]
+		}
+	}
+	return int(crc16(key) % clusterSlots)
+}
+
+// crc16 is the CRC16-CCITT (XMODEM) used by redis cluster
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
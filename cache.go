@@ -0,0 +1,160 @@
+package redis
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	xfetchFieldValue  = "value"
+	xfetchFieldDelta  = "delta"
+	xfetchFieldExpiry = "expiry"
+
+	defaultXFetchBeta     = 1.0
+	defaultXFetchLeaseTTL = 5 * time.Second
+	defaultXFetchWait     = 50 * time.Millisecond
+)
+
+// XFetchOptions for probabilistic early expiration
+type XFetchOptions struct {
+	// TTL of the cached value
+	TTL time.Duration
+	// Beta > 1 favors earlier recomputation, < 1 favors later, default 1
+	Beta float64
+	// LeaseTTL bounds how long one process may hold the recompute lease
+	LeaseTTL time.Duration
+	// Background returns the current value and recomputes in a goroutine
+	Background bool
+}
+
+// XFetchLoader computes the value to cache
+type XFetchLoader func() (string, error)
+
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// XFetch reads key and recomputes it with load before it expires using the XFetch algorithm,
+// only one process holding the lease recomputes at a time
+func (c Client) XFetch(key string, opt XFetchOptions, load XFetchLoader) (string, error) {
+	if opt.TTL <= 0 {
+		return "", errors.New("redis: xfetch ttl must be positive")
+	}
+	if opt.Beta <= 0 {
+		opt.Beta = defaultXFetchBeta
+	}
+	if opt.LeaseTTL <= 0 {
+		opt.LeaseTTL = defaultXFetchLeaseTTL
+	}
+
+	deadline := time.Now().Add(opt.LeaseTTL)
+	for {
+		value, delta, expiry, ok, err := c.xfetchGet(key)
+		if err != nil {
+			return "", err
+		}
+		if ok && !xfetchShouldRecompute(delta, expiry, opt.Beta) {
+			return value, nil
+		}
+
+		token := strconv.FormatInt(rand.Int63(), 36)
+		acquired, err := c.SetNX(xfetchLeaseKey(key), token, opt.LeaseTTL).Result()
+		if err != nil {
+			return "", err
+		}
+		if acquired {
+			if ok && opt.Background {
+				bg := Client{Client: c.Client}
+				go func() {
+					_, _ = bg.xfetchRecompute(key, token, opt, load)
+				}()
+				return value, nil
+			}
+			return c.xfetchRecompute(key, token, opt, load)
+		}
+
+		// another process is recomputing, serve the current value if we have one
+		if ok {
+			return value, nil
+		}
+		if time.Now().After(deadline) {
+			// the lease holder did not finish in time, compute without caching
+			return load()
+		}
+		sleepCtx(c.getCtx(), defaultXFetchWait)
+		if err := c.getCtx().Err(); err != nil {
+			return "", err
+		}
+	}
+}
+
+func (c Client) xfetchGet(key string) (value string, delta time.Duration, expiry time.Time, ok bool, err error) {
+	vals, err := c.HMGet(key, xfetchFieldValue, xfetchFieldDelta, xfetchFieldExpiry).Result()
+	if err != nil {
+		return "", 0, time.Time{}, false, err
+	}
+	v, ok1 := vals[0].(string)
+	d, ok2 := vals[1].(string)
+	e, ok3 := vals[2].(string)
+	if !ok1 || !ok2 || !ok3 {
+		return "", 0, time.Time{}, false, nil
+	}
+	deltaMs, err := strconv.ParseInt(d, 10, 64)
+	if err != nil {
+		return "", 0, time.Time{}, false, nil
+	}
+	expiryMs, err := strconv.ParseInt(e, 10, 64)
+	if err != nil {
+		return "", 0, time.Time{}, false, nil
+	}
+	return v, time.Duration(deltaMs) * time.Millisecond, time.Unix(0, expiryMs*int64(time.Millisecond)), true, nil
+}
+
+func (c Client) xfetchRecompute(key, token string, opt XFetchOptions, load XFetchLoader) (string, error) {
+	defer releaseLeaseScript.Run(c.getCtx(), c.Client, []string{xfetchLeaseKey(key)}, token)
+
+	start := time.Now()
+	value, err := load()
+	if err != nil {
+		return "", err
+	}
+	delta := time.Since(start)
+	expiry := time.Now().Add(opt.TTL)
+
+	_, err = c.TxPipelined(func(pipe redis.Pipeliner) error {
+		ctx := c.getCtx()
+		pipe.HSet(ctx, key,
+			xfetchFieldValue, value,
+			xfetchFieldDelta, delta.Milliseconds(),
+			xfetchFieldExpiry, expiry.UnixNano()/int64(time.Millisecond),
+		)
+		pipe.PExpire(ctx, key, opt.TTL)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// xfetchShouldRecompute implements now - delta * beta * ln(rand()) >= expiry
+func xfetchShouldRecompute(delta time.Duration, expiry time.Time, beta float64) bool {
+	r := rand.Float64()
+	if r == 0 {
+		return true
+	}
+	gap := time.Duration(-float64(delta) * beta * math.Log(r))
+	return !time.Now().Add(gap).Before(expiry)
+}
+
+func xfetchLeaseKey(key string) string {
+	return key + ":xfetch-lease"
+}
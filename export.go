@@ -0,0 +1,357 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	maxImportLine = 512 * 1024 * 1024
+	// importStreamGroup is the temporary group creating empty streams
+	importStreamGroup = "redis-import"
+)
+
+// ExportedKey is one line of an export, every string of a key with binary
+// data is base64 encoded
+type ExportedKey struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// TTL is the remaining ttl in milliseconds, 0 without expiry
+	TTL    int64 `json:"ttl,omitempty"`
+	Base64 bool  `json:"base64,omitempty"`
+	// Value is a string, an object for hashes, an array of strings for lists and sets,
+	// an array of ExportedZ for sorted sets and of ExportedStreamEntry for streams
+	Value json.RawMessage `json:"value"`
+}
+
+// ExportedZ is a member of an exported sorted set, the score is a string to allow +inf and -inf
+type ExportedZ struct {
+	Member string `json:"member"`
+	Score  string `json:"score"`
+}
+
+// ExportedStreamEntry is an entry of an exported stream
+type ExportedStreamEntry struct {
+	ID     string            `json:"id"`
+	Values map[string]string `json:"values"`
+}
+
+// ExportOptions for Export
+type ExportOptions struct {
+	// Match pattern of the keys, default all
+	Match string
+	// Type of the keys, default any
+	Type string
+	// ScanCount hint per Scan page
+	ScanCount int64
+}
+
+// ImportOptions for Import
+type ImportOptions struct {
+	// Match pattern of the keys imported, default all
+	Match string
+	// Replace existing keys, by default they are skipped
+	Replace bool
+}
+
+// Export writes every key as a JSON line to w and returns the number of keys written
+func (c Client) Export(w io.Writer, opt ExportOptions) (int64, error) {
+	ctx := c.getCtx()
+	enc := json.NewEncoder(w)
+	var n int64
+	err := c.ScanKeys(ScanOptions{Match: opt.Match, Count: opt.ScanCount, Type: opt.Type}, func(keys []string) error {
+		typeCmds := make([]*redis.StatusCmd, len(keys))
+		_, err := c.Pipelined(func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				typeCmds[i] = pipe.Type(ctx, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		valueCmds := make([]redis.Cmder, len(keys))
+		ttlCmds := make([]*redis.DurationCmd, len(keys))
+		_, err = c.Pipelined(func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				switch typeCmds[i].Val() {
+				case "string":
+					valueCmds[i] = pipe.Get(ctx, key)
+				case "hash":
+					valueCmds[i] = pipe.HGetAll(ctx, key)
+				case "list":
+					valueCmds[i] = pipe.LRange(ctx, key, 0, -1)
+				case "set":
+					valueCmds[i] = pipe.SMembers(ctx, key)
+				case "zset":
+					valueCmds[i] = pipe.ZRangeWithScores(ctx, key, 0, -1)
+				case "stream":
+					valueCmds[i] = pipe.XRange(ctx, key, "-", "+")
+				default:
+					continue
+				}
+				ttlCmds[i] = pipe.PTTL(ctx, key)
+			}
+			return nil
+		})
+		// keys deleted or replaced meanwhile fail with a reply error
+		if _, ok := err.(redis.Error); err != nil && !ok {
+			return err
+		}
+
+		for i, key := range keys {
+			if valueCmds[i] == nil || valueCmds[i].Err() != nil || ttlCmds[i].Val() == -2 {
+				continue
+			}
+			line, err := exportKey(key, typeCmds[i].Val(), valueCmds[i], ttlCmds[i].Val().Milliseconds())
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(line); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+func exportKey(key, typ string, cmd redis.Cmder, ttl int64) (*ExportedKey, error) {
+	line := &ExportedKey{Key: key, Type: typ}
+	if ttl > 0 {
+		line.TTL = ttl
+	}
+	// the first pass finds binary data, the second encodes it
+	binary := !utf8.ValidString(key)
+	value := exportValue(cmd, func(s string) string {
+		binary = binary || !utf8.ValidString(s)
+		return s
+	})
+	if binary {
+		line.Base64 = true
+		line.Key = base64.StdEncoding.EncodeToString([]byte(key))
+		value = exportValue(cmd, func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		})
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	line.Value = raw
+	return line, nil
+}
+
+func exportValue(cmd redis.Cmder, enc func(string) string) interface{} {
+	switch cmd := cmd.(type) {
+	case *redis.StringCmd:
+		return enc(cmd.Val())
+	case *redis.StringStringMapCmd:
+		fields := make(map[string]string, len(cmd.Val()))
+		for k, v := range cmd.Val() {
+			fields[enc(k)] = enc(v)
+		}
+		return fields
+	case *redis.StringSliceCmd:
+		members := make([]string, len(cmd.Val()))
+		for i, v := range cmd.Val() {
+			members[i] = enc(v)
+		}
+		return members
+	case *redis.ZSliceCmd:
+		members := make([]ExportedZ, len(cmd.Val()))
+		for i, z := range cmd.Val() {
+			members[i] = ExportedZ{
+				Member: enc(argString(z.Member)),
+				Score:  strconv.FormatFloat(z.Score, 'g', -1, 64),
+			}
+		}
+		return members
+	case *redis.XMessageSliceCmd:
+		entries := make([]ExportedStreamEntry, len(cmd.Val()))
+		for i, msg := range cmd.Val() {
+			values := make(map[string]string, len(msg.Values))
+			for k, v := range msg.Values {
+				values[enc(k)] = enc(argString(v))
+			}
+			entries[i] = ExportedStreamEntry{ID: msg.ID, Values: values}
+		}
+		return entries
+	}
+	return nil
+}
+
+// Import recreates the keys of an export read from r and returns the number of keys imported
+func (c Client) Import(r io.Reader, opt ImportOptions) (int64, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxImportLine)
+	var n int64
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var line ExportedKey
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return n, err
+		}
+		ok, err := c.importKey(&line, opt)
+		if err != nil {
+			return n, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, scanner.Err()
+}
+
+func (c Client) importKey(line *ExportedKey, opt ImportOptions) (bool, error) {
+	dec := func(s string) (string, error) {
+		return s, nil
+	}
+	if line.Base64 {
+		dec = func(s string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return string(b), err
+		}
+	}
+	key, err := dec(line.Key)
+	if err != nil {
+		return false, err
+	}
+	if opt.Match != "" && !globMatch(opt.Match, key) {
+		return false, nil
+	}
+	if !opt.Replace {
+		n, err := c.Exists(key).Result()
+		if err != nil || n > 0 {
+			return false, err
+		}
+	}
+
+	ctx := c.getCtx()
+	add, err := importValue(ctx, line, key, dec)
+	if err != nil {
+		return false, err
+	}
+	_, err = c.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		add(pipe)
+		if line.TTL > 0 {
+			pipe.PExpire(ctx, key, time.Duration(line.TTL)*time.Millisecond)
+		}
+		return nil
+	})
+	return err == nil, err
+}
+
+// importValue decodes the value of line and returns the function queuing the commands recreating it
+func importValue(ctx context.Context, line *ExportedKey, key string, dec func(string) (string, error)) (func(redis.Pipeliner), error) {
+	var err error
+	decode := func(s string) string {
+		if err != nil {
+			return ""
+		}
+		var v string
+		v, err = dec(s)
+		return v
+	}
+
+	var add func(redis.Pipeliner)
+	switch line.Type {
+	case "string":
+		var s string
+		if err := json.Unmarshal(line.Value, &s); err != nil {
+			return nil, err
+		}
+		v := decode(s)
+		add = func(pipe redis.Pipeliner) {
+			pipe.Set(ctx, key, v, 0)
+		}
+	case "hash":
+		var fields map[string]string
+		if err := json.Unmarshal(line.Value, &fields); err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0, 2*len(fields))
+		for k, v := range fields {
+			values = append(values, decode(k), decode(v))
+		}
+		add = func(pipe redis.Pipeliner) {
+			pipe.HSet(ctx, key, values...)
+		}
+	case "list", "set":
+		var members []string
+		if err := json.Unmarshal(line.Value, &members); err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(members))
+		for i, m := range members {
+			values[i] = decode(m)
+		}
+		add = func(pipe redis.Pipeliner) {
+			if line.Type == "list" {
+				pipe.RPush(ctx, key, values...)
+			} else {
+				pipe.SAdd(ctx, key, values...)
+			}
+		}
+	case "zset":
+		var members []ExportedZ
+		if err := json.Unmarshal(line.Value, &members); err != nil {
+			return nil, err
+		}
+		zs := make([]*redis.Z, len(members))
+		for i, m := range members {
+			score, err := strconv.ParseFloat(m.Score, 64)
+			if err != nil {
+				return nil, err
+			}
+			zs[i] = &redis.Z{Member: decode(m.Member), Score: score}
+		}
+		add = func(pipe redis.Pipeliner) {
+			pipe.ZAdd(ctx, key, zs...)
+		}
+	case "stream":
+		var entries []ExportedStreamEntry
+		if err := json.Unmarshal(line.Value, &entries); err != nil {
+			return nil, err
+		}
+		args := make([]*redis.XAddArgs, len(entries))
+		for i, entry := range entries {
+			values := make(map[string]interface{}, len(entry.Values))
+			for k, v := range entry.Values {
+				values[decode(k)] = decode(v)
+			}
+			args[i] = &redis.XAddArgs{Stream: key, ID: entry.ID, Values: values}
+		}
+		add = func(pipe redis.Pipeliner) {
+			// a stream emptied by XDEL or XTRIM, or created by MKSTREAM, still exists
+			if len(args) == 0 {
+				pipe.XGroupCreateMkStream(ctx, key, importStreamGroup, "$")
+				pipe.XGroupDestroy(ctx, key, importStreamGroup)
+				return
+			}
+			for _, a := range args {
+				pipe.XAdd(ctx, a)
+			}
+		}
+	default:
+		return nil, errors.New("redis: import unsupported type " + line.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return add, nil
+}
@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DangerousCommands denied by Config.DenyDangerous
+var DangerousCommands = []string{
+	"flushall",
+	"flushdb",
+	"keys",
+	"shutdown",
+	"config set",
+	"config resetstat",
+	"config rewrite",
+	"client kill",
+	"client pause",
+	"slaveof",
+	"replicaof",
+	"debug",
+	"script flush",
+	"script kill",
+	"cluster reset",
+	"cluster failover",
+}
+
+// GuardOverride allows a denied command, e.g. for admin tools
+type GuardOverride func(ctx context.Context, command string) bool
+
+// CommandDeniedError is returned for commands denied by the safety policy,
+// they are never sent to the server
+type CommandDeniedError struct {
+	Command string
+	Reason  string
+}
+
+func (e *CommandDeniedError) Error() string {
+	return "redis: command " + e.Command + " denied: " + e.Reason
+}
+
+// subcommands of the commands whose policy is per subcommand
+var subcommandNames = map[string]bool{
+	"acl": true, "client": true, "cluster": true, "command": true, "config": true,
+	"debug": true, "latency": true, "memory": true, "object": true, "pubsub": true,
+	"script": true, "slowlog": true, "xgroup": true, "xinfo": true,
+}
+
+// readCommands are allowed in read-only mode, every other command is a write
+var readCommands = map[string]bool{
+	"ping": true, "echo": true, "quit": true, "auth": true, "hello": true, "select": true,
+	"info": true, "time": true, "dbsize": true, "lastsave": true, "role": true,
+	"command": true, "command count": true, "command info": true, "command getkeys": true,
+	"client getname": true, "client id": true, "client list": true, "client info": true,
+	"config get": true, "memory usage": true, "memory stats": true, "slowlog get": true, "slowlog len": true,
+	"readonly": true, "readwrite": true, "watch": true, "unwatch": true,
+	"cluster info": true, "cluster nodes": true, "cluster slots": true, "cluster keyslot": true,
+	"cluster countkeysinslot": true, "cluster getkeysinslot": true, "cluster slaves": true,
+	"cluster count-failure-reports": true, "pubsub channels": true, "pubsub numsub": true, "pubsub numpat": true,
+	"subscribe": true, "psubscribe": true, "unsubscribe": true, "punsubscribe": true,
+	"script exists": true,
+
+	"exists": true, "type": true, "ttl": true, "pttl": true, "dump": true, "keys": true,
+	"scan": true, "randomkey": true, "touch": true, "sort_ro": true,
+	"object encoding": true, "object freq": true, "object idletime": true, "object refcount": true,
+	"get": true, "getrange": true, "mget": true, "strlen": true, "getbit": true,
+	"bitcount": true, "bitpos": true, "bitfield_ro": true,
+	"hget": true, "hgetall": true, "hmget": true, "hkeys": true, "hvals": true, "hlen": true,
+	"hexists": true, "hstrlen": true, "hscan": true, "hrandfield": true,
+	"lindex": true, "llen": true, "lrange": true, "lpos": true,
+	"scard": true, "sismember": true, "smismember": true, "smembers": true, "srandmember": true,
+	"sscan": true, "sdiff": true, "sinter": true, "sunion": true,
+	"zcard": true, "zcount": true, "zlexcount": true, "zscore": true, "zmscore": true,
+	"zrank": true, "zrevrank": true, "zrange": true, "zrangebyscore": true, "zrangebylex": true,
+	"zrevrange": true, "zrevrangebyscore": true, "zrevrangebylex": true, "zscan": true,
+	"zrandmember": true, "zunion": true, "zinter": true, "zdiff": true, "pfcount": true,
+	"xlen": true, "xrange": true, "xrevrange": true, "xread": true, "xpending": true,
+	"xinfo stream": true, "xinfo groups": true, "xinfo consumers": true,
+	"geopos": true, "geodist": true, "geohash": true, "geosearch": true,
+	"georadius_ro": true, "georadiusbymember_ro": true,
+}
+
+// guardHook rejects the commands denied by the safety policy before they reach the network
+type guardHook struct {
+	deny     map[string]bool
+	allow    map[string]bool
+	readOnly bool
+	override GuardOverride
+}
+
+func newGuardHook(conf *Config) *guardHook {
+	h := &guardHook{readOnly: conf.ReadOnly, override: conf.GuardOverride}
+	if conf.DenyDangerous || len(conf.DenyCommands) > 0 {
+		h.deny = commandSet(conf.DenyCommands)
+		if conf.DenyDangerous {
+			for _, name := range DangerousCommands {
+				h.deny[name] = true
+			}
+		}
+	}
+	if len(conf.AllowCommands) > 0 {
+		h.allow = commandSet(conf.AllowCommands)
+	}
+	if h.deny == nil && h.allow == nil && !h.readOnly {
+		return nil
+	}
+	return h
+}
+
+func commandSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(strings.Join(strings.Fields(name), " "))] = true
+	}
+	return set
+}
+
+func (h *guardHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, h.check(ctx, cmd)
+}
+
+func (h *guardHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *guardHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if isAutoPipelineBatch(ctx) {
+		return ctx, nil
+	}
+	for _, cmd := range cmds {
+		if err := h.check(ctx, cmd); err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+func (h *guardHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func (h *guardHook) check(ctx context.Context, cmd redis.Cmder) error {
+	name := cmd.Name()
+	// the markers of TxPipelined are not commands of their own
+	if name == "multi" || name == "exec" || name == "discard" {
+		return nil
+	}
+	command := commandName(cmd)
+	reason := h.denyReason(name, command)
+	if reason == "" {
+		return nil
+	}
+	if h.override != nil && h.override(ctx, command) {
+		return nil
+	}
+	return &CommandDeniedError{Command: command, Reason: reason}
+}
+
+func (h *guardHook) denyReason(name, command string) string {
+	if h.deny[name] || h.deny[command] {
+		return "deny-listed"
+	}
+	if h.allow != nil && !h.allow[name] && !h.allow[command] {
+		return "not allow-listed"
+	}
+	if h.readOnly && !readCommands[name] && !readCommands[command] {
+		return "write on read-only client"
+	}
+	return ""
+}
+
+// commandName returns the lower cased command name with its subcommand, e.g. "config set"
+func commandName(cmd redis.Cmder) string {
+	name := cmd.Name()
+	args := cmd.Args()
+	if !subcommandNames[name] || len(args) < 2 {
+		return name
+	}
+	sub, ok := args[1].(string)
+	if !ok {
+		return name
+	}
+	return name + " " + strings.ToLower(sub)
+}
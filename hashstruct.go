@@ -0,0 +1,379 @@
+package redis
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// HashMarshaler is implemented by field types with a custom hash field encoding
+type HashMarshaler interface {
+	MarshalHash() (string, error)
+}
+
+// HashUnmarshaler is implemented by field types with a custom hash field decoding
+type HashUnmarshaler interface {
+	UnmarshalHash(s string) error
+}
+
+// FieldError of a struct field mapped to a hash field
+type FieldError struct {
+	// Field is the struct field path, e.g. "Profile.Age"
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return "redis: field " + e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	hashMarshalerType   = reflect.TypeOf((*HashMarshaler)(nil)).Elem()
+	hashUnmarshalerType = reflect.TypeOf((*HashUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	bytesType           = reflect.TypeOf([]byte(nil))
+)
+
+// hashField is a struct field tagged `redis:"name,omitempty"`
+type hashField struct {
+	name      string
+	path      string
+	index     []int
+	omitEmpty bool
+}
+
+// hashFields returns the tagged fields of struct type t, embedded structs included
+func hashFields(t reflect.Type) []hashField {
+	var fields []hashField
+	var walk func(t reflect.Type, index []int, path string)
+	walk = func(t reflect.Type, index []int, path string) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			fieldIndex := append(append([]int(nil), index...), i)
+			tag, ok := f.Tag.Lookup("redis")
+			if tag == "-" {
+				continue
+			}
+			if !ok {
+				ft := f.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if f.Anonymous && ft.Kind() == reflect.Struct {
+					walk(ft, fieldIndex, path+f.Name+".")
+				}
+				continue
+			}
+			if f.PkgPath != "" {
+				continue
+			}
+			name, opts := tag, ""
+			if i := strings.IndexByte(tag, ','); i >= 0 {
+				name, opts = tag[:i], tag[i+1:]
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fields = append(fields, hashField{
+				name:      name,
+				path:      path + f.Name,
+				index:     fieldIndex,
+				omitEmpty: opts == "omitempty",
+			})
+		}
+	}
+	walk(t, nil, "")
+	return fields
+}
+
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return rv, errors.New("redis: nil struct pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return rv, errors.New("redis: expected a struct, got " + rv.Type().String())
+	}
+	return rv, nil
+}
+
+// fieldByIndex returns the field at index, allocating nil embedded pointers when alloc is set,
+// it returns the nil embedded pointer and false when it stops there
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				// a pointer to an unexported struct can't be allocated through reflection
+				if !alloc || !v.CanSet() {
+					return v, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// StructToHash returns the field value pairs of the tagged fields of v for HSet,
+// nil pointers and empty omitempty fields are left out
+func StructToHash(v interface{}) ([]interface{}, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := hashFields(rv.Type())
+	values := make([]interface{}, 0, 2*len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index, false)
+		if !ok {
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		s, ok, err := encodeHashValue(fv)
+		if err != nil {
+			return nil, &FieldError{Field: f.path, Err: err}
+		}
+		if ok {
+			values = append(values, f.name, s)
+		}
+	}
+	return values, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).IsZero()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// encodeHashValue returns the hash field of v, false for a nil pointer
+func encodeHashValue(v reflect.Value) (string, bool, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	if m, ok := marshaler(v, hashMarshalerType); ok {
+		s, err := m.(HashMarshaler).MarshalHash()
+		return s, true, err
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), true, nil
+	}
+	if m, ok := marshaler(v, textMarshalerType); ok {
+		b, err := m.(encoding.TextMarshaler).MarshalText()
+		return string(b), true, err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), true, nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", true, nil
+		}
+		return "0", true, nil
+	}
+	if v.Type() == bytesType {
+		return string(v.Bytes()), true, nil
+	}
+	return "", false, errors.New("unsupported type " + v.Type().String())
+}
+
+// marshaler returns v or its address as an interface value if it implements iface
+func marshaler(v reflect.Value, iface reflect.Type) (interface{}, bool) {
+	if v.Type().Implements(iface) {
+		return v.Interface(), true
+	}
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(iface) {
+		return v.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+// ScanHash sets the tagged fields of the struct pointer v from the result of HGetAll,
+// the fields missing in the hash are left unchanged
+func ScanHash(hash map[string]string, v interface{}) error {
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	if !rv.CanAddr() {
+		return errors.New("redis: expected a struct pointer")
+	}
+	for _, f := range hashFields(rv.Type()) {
+		s, ok := hash[f.name]
+		if !ok {
+			continue
+		}
+		fv, ok := fieldByIndex(rv, f.index, true)
+		if !ok {
+			return &FieldError{Field: f.path, Err: errors.New("cannot set embedded pointer to unexported struct " + fv.Type().Elem().String())}
+		}
+		if err := decodeHashValue(fv, s); err != nil {
+			return &FieldError{Field: f.path, Err: err}
+		}
+	}
+	return nil
+}
+
+// ScanHashValues sets the tagged fields of the struct pointer v from the result of
+// HMGet of names, the nil values are left unchanged
+func ScanHashValues(names []string, vals []interface{}, v interface{}) error {
+	hash := make(map[string]string, len(names))
+	for i, name := range names {
+		if i < len(vals) && vals[i] != nil {
+			hash[name] = argString(vals[i])
+		}
+	}
+	return ScanHash(hash, v)
+}
+
+// HashFieldNames returns the hash fields of the struct v, e.g. for HMGet
+func HashFieldNames(v interface{}) ([]string, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := hashFields(rv.Type())
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names, nil
+}
+
+func decodeHashValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if u, ok := marshaler(v, hashUnmarshalerType); ok {
+		return u.(HashUnmarshaler).UnmarshalHash(s)
+	}
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if u, ok := marshaler(v, textUnmarshalerType); ok {
+		return u.(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+	}
+	if v.Type() == bytesType {
+		v.SetBytes([]byte(s))
+		return nil
+	}
+	return errors.New("unsupported type " + v.Type().String())
+}
+
+// errNoHashFields is returned by HSetStruct when every field is nil or omitted
+var errNoHashFields = errors.New("redis: struct has no hash field to set")
+
+// HSetStruct sets the tagged fields of the struct v in the hash key
+func (c Client) HSetStruct(key string, v interface{}) *redis.IntCmd {
+	values, err := StructToHash(v)
+	if err == nil && len(values) == 0 {
+		err = errNoHashFields
+	}
+	if err != nil {
+		cmd := redis.NewIntCmd(c.getCtx(), "hset", key)
+		cmd.SetErr(err)
+		return cmd
+	}
+	return c.HSet(key, values...)
+}
+
+// HGetAllStruct sets the tagged fields of the struct pointer v from the hash key,
+// it returns redis.Nil if the hash doesn't exist
+func (c Client) HGetAllStruct(key string, v interface{}) error {
+	hash, err := c.HGetAll(key).Result()
+	if err != nil {
+		return err
+	}
+	if len(hash) == 0 {
+		return redis.Nil
+	}
+	return ScanHash(hash, v)
+}
+
+// HMGetStruct sets the tagged fields of the struct pointer v from the hash key with HMGet
+func (c Client) HMGetStruct(key string, v interface{}) error {
+	names, err := HashFieldNames(v)
+	if err != nil {
+		return err
+	}
+	vals, err := c.HMGet(key, names...).Result()
+	if err != nil {
+		return err
+	}
+	return ScanHashValues(names, vals, v)
+}
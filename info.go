@@ -0,0 +1,297 @@
+package redis
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Info is the typed INFO output, the sections not requested are empty
+type Info struct {
+	Server      InfoServer
+	Clients     InfoClients
+	Memory      InfoMemory
+	Persistence InfoPersistence
+	Stats       InfoStats
+	Replication InfoReplication
+	CPU         InfoCPU
+	// Keyspace per db number
+	Keyspace map[int]InfoKeyspace
+	// Other are the fields of the other sections per section name
+	Other map[string]map[string]string
+}
+
+// InfoServer section of INFO
+type InfoServer struct {
+	RedisVersion    string `info:"redis_version"`
+	RedisMode       string `info:"redis_mode"`
+	OS              string `info:"os"`
+	ArchBits        int64  `info:"arch_bits"`
+	ProcessID       int64  `info:"process_id"`
+	RunID           string `info:"run_id"`
+	TCPPort         int64  `info:"tcp_port"`
+	UptimeInSeconds int64  `info:"uptime_in_seconds"`
+	ConfigFile      string `info:"config_file"`
+	// Extra are the fields not above
+	Extra map[string]string
+}
+
+// InfoClients section of INFO
+type InfoClients struct {
+	ConnectedClients            int64 `info:"connected_clients"`
+	ClusterConnections          int64 `info:"cluster_connections"`
+	MaxClients                  int64 `info:"maxclients"`
+	ClientRecentMaxInputBuffer  int64 `info:"client_recent_max_input_buffer"`
+	ClientRecentMaxOutputBuffer int64 `info:"client_recent_max_output_buffer"`
+	BlockedClients              int64 `info:"blocked_clients"`
+	TrackingClients             int64 `info:"tracking_clients"`
+	// Extra are the fields not above
+	Extra map[string]string
+}
+
+// InfoMemory section of INFO
+type InfoMemory struct {
+	UsedMemory            int64   `info:"used_memory"`
+	UsedMemoryRSS         int64   `info:"used_memory_rss"`
+	UsedMemoryPeak        int64   `info:"used_memory_peak"`
+	UsedMemoryDataset     int64   `info:"used_memory_dataset"`
+	UsedMemoryLua         int64   `info:"used_memory_lua"`
+	MaxMemory             int64   `info:"maxmemory"`
+	MaxMemoryPolicy       string  `info:"maxmemory_policy"`
+	MemFragmentationRatio float64 `info:"mem_fragmentation_ratio"`
+	MemAllocator          string  `info:"mem_allocator"`
+	// Extra are the fields not above
+	Extra map[string]string
+}
+
+// InfoPersistence section of INFO
+type InfoPersistence struct {
+	Loading                 bool   `info:"loading"`
+	RDBChangesSinceLastSave int64  `info:"rdb_changes_since_last_save"`
+	RDBBgsaveInProgress     bool   `info:"rdb_bgsave_in_progress"`
+	RDBLastSaveTime         int64  `info:"rdb_last_save_time"`
+	RDBLastBgsaveStatus     string `info:"rdb_last_bgsave_status"`
+	AOFEnabled              bool   `info:"aof_enabled"`
+	AOFRewriteInProgress    bool   `info:"aof_rewrite_in_progress"`
+	AOFLastBgrewriteStatus  string `info:"aof_last_bgrewrite_status"`
+	AOFLastWriteStatus      string `info:"aof_last_write_status"`
+	// Extra are the fields not above
+	Extra map[string]string
+}
+
+// InfoStats section of INFO
+type InfoStats struct {
+	TotalConnectionsReceived int64   `info:"total_connections_received"`
+	TotalCommandsProcessed   int64   `info:"total_commands_processed"`
+	InstantaneousOpsPerSec   int64   `info:"instantaneous_ops_per_sec"`
+	TotalNetInputBytes       int64   `info:"total_net_input_bytes"`
+	TotalNetOutputBytes      int64   `info:"total_net_output_bytes"`
+	InstantaneousInputKbps   float64 `info:"instantaneous_input_kbps"`
+	InstantaneousOutputKbps  float64 `info:"instantaneous_output_kbps"`
+	RejectedConnections      int64   `info:"rejected_connections"`
+	ExpiredKeys              int64   `info:"expired_keys"`
+	EvictedKeys              int64   `info:"evicted_keys"`
+	KeyspaceHits             int64   `info:"keyspace_hits"`
+	KeyspaceMisses           int64   `info:"keyspace_misses"`
+	PubsubChannels           int64   `info:"pubsub_channels"`
+	PubsubPatterns           int64   `info:"pubsub_patterns"`
+	LatestForkUsec           int64   `info:"latest_fork_usec"`
+	// Extra are the fields not above
+	Extra map[string]string
+}
+
+// InfoReplication section of INFO
+type InfoReplication struct {
+	Role             string `info:"role"`
+	ConnectedSlaves  int64  `info:"connected_slaves"`
+	MasterHost       string `info:"master_host"`
+	MasterPort       int64  `info:"master_port"`
+	MasterLinkStatus string `info:"master_link_status"`
+	MasterReplID     string `info:"master_replid"`
+	MasterReplOffset int64  `info:"master_repl_offset"`
+	SlaveReplOffset  int64  `info:"slave_repl_offset"`
+	// Replicas are the slaveN fields
+	Replicas []InfoReplica
+	// Extra are the fields not above
+	Extra map[string]string
+}
+
+// InfoReplica is a replica of the replication section
+type InfoReplica struct {
+	IP     string
+	Port   int64
+	State  string
+	Offset int64
+	Lag    int64
+}
+
+// InfoCPU section of INFO
+type InfoCPU struct {
+	UsedCPUSys          float64 `info:"used_cpu_sys"`
+	UsedCPUUser         float64 `info:"used_cpu_user"`
+	UsedCPUSysChildren  float64 `info:"used_cpu_sys_children"`
+	UsedCPUUserChildren float64 `info:"used_cpu_user_children"`
+	// Extra are the fields not above
+	Extra map[string]string
+}
+
+// InfoKeyspace of a db
+type InfoKeyspace struct {
+	Keys    int64
+	Expires int64
+	// AvgTTL in milliseconds
+	AvgTTL int64
+}
+
+// ParsedInfo returns the typed INFO output of the sections, default the default sections
+func (c Client) ParsedInfo(section ...string) (*Info, error) {
+	s, err := c.Info(section...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfo(s), nil
+}
+
+// ParseInfo parses the output of INFO, fields which don't parse are kept in Extra
+func ParseInfo(s string) *Info {
+	info := &Info{}
+	sections := map[string]interface{}{
+		"server":      &info.Server,
+		"clients":     &info.Clients,
+		"memory":      &info.Memory,
+		"persistence": &info.Persistence,
+		"stats":       &info.Stats,
+		"replication": &info.Replication,
+		"cpu":         &info.CPU,
+	}
+
+	var section string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line[0] == '#' {
+			section = strings.ToLower(strings.TrimSpace(line[1:]))
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		name, value := line[:i], line[i+1:]
+
+		switch {
+		case section == "keyspace" && strings.HasPrefix(name, "db"):
+			db, err := strconv.Atoi(name[2:])
+			if err == nil {
+				if info.Keyspace == nil {
+					info.Keyspace = make(map[int]InfoKeyspace)
+				}
+				info.Keyspace[db] = parseInfoKeyspace(value)
+				continue
+			}
+		case section == "replication" && isInfoReplica(name, value):
+			info.Replication.Replicas = append(info.Replication.Replicas, parseInfoReplica(value))
+			continue
+		}
+		if dst, ok := sections[section]; ok && setInfoField(dst, name, value) {
+			continue
+		}
+		if dst, ok := sections[section]; ok {
+			extra := reflect.ValueOf(dst).Elem().FieldByName("Extra")
+			if extra.IsNil() {
+				extra.Set(reflect.MakeMap(extra.Type()))
+			}
+			extra.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(value))
+			continue
+		}
+		if info.Other == nil {
+			info.Other = make(map[string]map[string]string)
+		}
+		if info.Other[section] == nil {
+			info.Other[section] = make(map[string]string)
+		}
+		info.Other[section][name] = value
+	}
+	return info
+}
+
+// setInfoField sets the field of dst tagged name, it returns false if there
+// is none or value doesn't parse
+func setInfoField(dst interface{}, name, value string) bool {
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("info") != name {
+			continue
+		}
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(value)
+		case reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return false
+			}
+			f.SetInt(n)
+		case reflect.Float64:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false
+			}
+			f.SetFloat(n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return false
+			}
+			f.SetBool(b)
+		default:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// parseInfoFields parses the a=1,b=2 values of the keyspace and replica fields
+func parseInfoFields(value string) map[string]string {
+	fields := make(map[string]string)
+	for _, kv := range strings.Split(value, ",") {
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			fields[kv[:i]] = kv[i+1:]
+		}
+	}
+	return fields
+}
+
+func parseInfoKeyspace(value string) InfoKeyspace {
+	fields := parseInfoFields(value)
+	var ks InfoKeyspace
+	ks.Keys, _ = strconv.ParseInt(fields["keys"], 10, 64)
+	ks.Expires, _ = strconv.ParseInt(fields["expires"], 10, 64)
+	ks.AvgTTL, _ = strconv.ParseInt(fields["avg_ttl"], 10, 64)
+	return ks
+}
+
+// isInfoReplica reports whether name is a slaveN field, redis before 2.8 used ip,port,state
+func isInfoReplica(name, value string) bool {
+	if !strings.HasPrefix(name, "slave") || len(name) == len("slave") {
+		return false
+	}
+	if _, err := strconv.Atoi(name[len("slave"):]); err != nil {
+		return false
+	}
+	return strings.Contains(value, "=")
+}
+
+func parseInfoReplica(value string) InfoReplica {
+	fields := parseInfoFields(value)
+	r := InfoReplica{IP: fields["ip"], State: fields["state"]}
+	r.Port, _ = strconv.ParseInt(fields["port"], 10, 64)
+	r.Offset, _ = strconv.ParseInt(fields["offset"], 10, 64)
+	r.Lag, _ = strconv.ParseInt(fields["lag"], 10, 64)
+	return r
+}
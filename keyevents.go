@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const notifyKeyspaceEvents = "notify-keyspace-events"
+
+// KeyEventsOptions for key events
+type KeyEventsOptions struct {
+	// DB of the keys, default the db of the client
+	DB *int
+	// Match pattern of the keys, default all, the keys are not prefixed with the client KeyPrefix
+	Match string
+	// Events delivered, e.g. "expired", "del", "set", "hset", default all
+	Events []string
+	// Enable sets the notify-keyspace-events flags needed by Events, keeping the existing ones
+	Enable bool
+	// Buffer of the events channel
+	Buffer int
+}
+
+// KeyEvent is a keyspace notification
+type KeyEvent struct {
+	DB    int
+	Key   string
+	Event string
+}
+
+// KeyEvents exposes keyspace notifications as a channel of KeyEvent
+type KeyEvents struct {
+	client Client
+	opt    KeyEventsOptions
+	events map[string]bool
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewKeyEvents return the key events subscriber
+func (c Client) NewKeyEvents(opt KeyEventsOptions) *KeyEvents {
+	if opt.DB == nil {
+		db := c.Options().DB
+		opt.DB = &db
+	}
+	if opt.Match == "" {
+		opt.Match = "*"
+	}
+	var events map[string]bool
+	if len(opt.Events) > 0 {
+		events = make(map[string]bool, len(opt.Events))
+		for _, event := range opt.Events {
+			events[event] = true
+		}
+	}
+	return &KeyEvents{
+		client: Client{Client: c.Client},
+		opt:    opt,
+		events: events,
+	}
+}
+
+// Events subscribes to the notifications until ctx is done or an error occurs,
+// then closes the channel
+func (e *KeyEvents) Events(ctx context.Context) <-chan KeyEvent {
+	ch := make(chan KeyEvent, e.opt.Buffer)
+	go func() {
+		defer close(ch)
+		e.setErr(e.run(ctx, ch))
+	}()
+	return ch
+}
+
+// Err returns the error that closed the events channel
+func (e *KeyEvents) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+func (e *KeyEvents) setErr(err error) {
+	e.mu.Lock()
+	e.err = err
+	e.mu.Unlock()
+}
+
+// EnableNotifications merges the notify-keyspace-events flags needed by the events into the server config
+func (e *KeyEvents) EnableNotifications(ctx context.Context) error {
+	client := e.client.Ctx(ctx)
+	config, err := client.ConfigGetMap(notifyKeyspaceEvents)
+	if err != nil {
+		return err
+	}
+	flags := config[notifyKeyspaceEvents]
+	merged := mergeNotifyFlags(flags, e.notifyFlags())
+	if merged == flags {
+		return nil
+	}
+	return client.ConfigSet(notifyKeyspaceEvents, merged).Err()
+}
+
+// notifyFlags returns the flags of keyspace notifications for the events
+func (e *KeyEvents) notifyFlags() string {
+	if e.events == nil {
+		return "KA"
+	}
+	flags := "K"
+	for event := range e.events {
+		flags = mergeNotifyFlags(flags, eventNotifyFlag(event))
+	}
+	return flags
+}
+
+// eventNotifyFlag returns the notify-keyspace-events class of event
+func eventNotifyFlag(event string) string {
+	switch event {
+	case "del", "expire", "rename_from", "rename_to", "copy_to", "move_from", "move_to", "restore", "persist", "sortstore":
+		return "g"
+	case "expired":
+		return "x"
+	case "evicted":
+		return "e"
+	case "new":
+		return "n"
+	case "keymiss":
+		return "m"
+	case "set", "setrange", "incrby", "incrbyfloat", "append":
+		return "$"
+	}
+	switch {
+	case strings.HasPrefix(event, "x"):
+		return "t"
+	case strings.HasPrefix(event, "h"):
+		return "h"
+	case strings.HasPrefix(event, "z"):
+		return "z"
+	case strings.HasPrefix(event, "s"):
+		return "s"
+	case strings.HasPrefix(event, "l"), strings.HasPrefix(event, "r"):
+		return "l"
+	}
+	return "A"
+}
+
+// mergeNotifyFlags adds the missing flags of add to flags, A is the alias of g$lshzxet
+func mergeNotifyFlags(flags, add string) string {
+	has := func(f rune) bool {
+		return strings.ContainsRune(flags, f) || (strings.ContainsRune("g$lshzxet", f) && strings.ContainsRune(flags, 'A'))
+	}
+	for _, f := range add {
+		if !has(f) {
+			flags += string(f)
+		}
+	}
+	return flags
+}
+
+func (e *KeyEvents) run(ctx context.Context, ch chan<- KeyEvent) error {
+	if e.opt.Enable {
+		if err := e.EnableNotifications(ctx); err != nil {
+			return err
+		}
+	}
+
+	pattern := "__keyspace@" + strconv.Itoa(*e.opt.DB) + "__:" + e.opt.Match
+	pubsub := e.client.Client.PSubscribe(ctx, pattern)
+	defer pubsub.Close()
+	// the confirmation surfaces subscription errors
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	msgs := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+			event, ok := parseKeyEvent(msg.Channel, msg.Payload)
+			if !ok || (e.events != nil && !e.events[event.Event]) {
+				continue
+			}
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// parseKeyEvent parses a __keyspace@<db>__:<key> notification
+func parseKeyEvent(channel, payload string) (KeyEvent, bool) {
+	const prefix = "__keyspace@"
+	if !strings.HasPrefix(channel, prefix) {
+		return KeyEvent{}, false
+	}
+	rest := channel[len(prefix):]
+	i := strings.Index(rest, "__:")
+	if i < 0 {
+		return KeyEvent{}, false
+	}
+	db, err := strconv.Atoi(rest[:i])
+	if err != nil {
+		return KeyEvent{}, false
+	}
+	return KeyEvent{DB: db, Key: rest[i+3:], Event: payload}, true
+}
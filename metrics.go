@@ -0,0 +1,225 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// MetricsBuckets are the upper bounds in seconds of the latency histograms
+var MetricsBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+type metricsStartKey struct{}
+
+type commandMetrics struct {
+	count   uint64
+	errors  map[string]uint64
+	buckets []uint64
+	sum     float64
+}
+
+// metricsHook records per command counts, errors and latencies
+type metricsHook struct {
+	client *redis.Client
+
+	mu       sync.Mutex
+	commands map[string]*commandMetrics
+}
+
+func newMetricsHook(client *redis.Client) *metricsHook {
+	return &metricsHook{client: client, commands: make(map[string]*commandMetrics)}
+}
+
+func (h *metricsHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, metricsStartKey{}, time.Now()), nil
+}
+
+func (h *metricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	start, ok := ctx.Value(metricsStartKey{}).(time.Time)
+	if !ok {
+		return nil
+	}
+	h.observe(commandName(cmd), time.Since(start), commandErrorClass(ctx, cmd))
+	return nil
+}
+
+func (h *metricsHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	// the commands of a batch are recorded one by one with the latency seen by their caller
+	if isAutoPipelineBatch(ctx) {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, metricsStartKey{}, time.Now()), nil
+}
+
+func (h *metricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	start, ok := ctx.Value(metricsStartKey{}).(time.Time)
+	if !ok || isAutoPipelineBatch(ctx) {
+		return nil
+	}
+	elapsed := time.Since(start)
+	h.observe("pipeline", elapsed, "")
+	for _, cmd := range cmds {
+		name := cmd.Name()
+		if name == "multi" || name == "exec" {
+			continue
+		}
+		h.observe(commandName(cmd), elapsed, commandErrorClass(ctx, cmd))
+	}
+	return nil
+}
+
+func (h *metricsHook) observe(name string, elapsed time.Duration, class string) {
+	seconds := elapsed.Seconds()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.commands[name]
+	if !ok {
+		m = &commandMetrics{errors: make(map[string]uint64), buckets: make([]uint64, len(MetricsBuckets))}
+		h.commands[name] = m
+	}
+	m.count++
+	m.sum += seconds
+	for i, le := range MetricsBuckets {
+		if seconds <= le {
+			m.buckets[i]++
+		}
+	}
+	if class != "" {
+		m.errors[class]++
+	}
+}
+
+// commandErrorClass returns the error class of cmd, "breaker_fallback" if the breaker
+// rejected it and its Fallback served it
+func commandErrorClass(ctx context.Context, cmd redis.Cmder) string {
+	if res, _ := ctx.Value(breakerKey{}).(breakerResult); res.rejected && cmd.Err() == nil {
+		return "breaker_fallback"
+	}
+	return errorClass(cmd.Err())
+}
+
+// errorClass returns the class of err for the error counters, "" if it's not an error
+func errorClass(err error) string {
+	if err == nil || err == redis.Nil {
+		return ""
+	}
+	var denied *CommandDeniedError
+	var open *BreakerOpenError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &denied):
+		return "denied"
+	case errors.As(err, &open):
+		return "breaker_open"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline"
+	case err.Error() == "redis: connection pool timeout":
+		return "pool_timeout"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	}
+	if _, ok := err.(redis.Error); ok {
+		return "server"
+	}
+	return "other"
+}
+
+// ServeHTTP writes the metrics in Prometheus text exposition format
+func (h *metricsHook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	h.writeCommands(bw)
+	h.writePool(bw)
+	_ = bw.Flush()
+}
+
+func (h *metricsHook) writeCommands(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	names := make([]string, 0, len(h.commands))
+	for name := range h.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writeMetricHeader(w, "redis_commands_total", "counter", "Number of commands processed.")
+	for _, name := range names {
+		fmt.Fprintf(w, "redis_commands_total{cmd=\"%s\"} %d\n", escapeLabel(name), h.commands[name].count)
+	}
+
+	writeMetricHeader(w, "redis_command_errors_total", "counter", "Number of commands failed by error class, breaker_fallback counts the rejections served by the breaker fallback.")
+	for _, name := range names {
+		m := h.commands[name]
+		classes := make([]string, 0, len(m.errors))
+		for class := range m.errors {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Fprintf(w, "redis_command_errors_total{cmd=\"%s\",class=\"%s\"} %d\n", escapeLabel(name), class, m.errors[class])
+		}
+	}
+
+	writeMetricHeader(w, "redis_command_duration_seconds", "histogram", "Latency of commands in seconds.")
+	for _, name := range names {
+		m := h.commands[name]
+		label := escapeLabel(name)
+		for i, le := range MetricsBuckets {
+			fmt.Fprintf(w, "redis_command_duration_seconds_bucket{cmd=\"%s\",le=\"%s\"} %d\n",
+				label, strconv.FormatFloat(le, 'g', -1, 64), m.buckets[i])
+		}
+		fmt.Fprintf(w, "redis_command_duration_seconds_bucket{cmd=\"%s\",le=\"+Inf\"} %d\n", label, m.count)
+		fmt.Fprintf(w, "redis_command_duration_seconds_sum{cmd=\"%s\"} %s\n", label, strconv.FormatFloat(m.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "redis_command_duration_seconds_count{cmd=\"%s\"} %d\n", label, m.count)
+	}
+}
+
+func (h *metricsHook) writePool(w *bufio.Writer) {
+	stats := h.client.PoolStats()
+	writeMetricHeader(w, "redis_pool_hits_total", "counter", "Number of times a free connection was found in the pool.")
+	fmt.Fprintf(w, "redis_pool_hits_total %d\n", stats.Hits)
+	writeMetricHeader(w, "redis_pool_misses_total", "counter", "Number of times a free connection was not found in the pool.")
+	fmt.Fprintf(w, "redis_pool_misses_total %d\n", stats.Misses)
+	writeMetricHeader(w, "redis_pool_timeouts_total", "counter", "Number of times a wait for a connection timed out.")
+	fmt.Fprintf(w, "redis_pool_timeouts_total %d\n", stats.Timeouts)
+	writeMetricHeader(w, "redis_pool_stale_conns_total", "counter", "Number of stale connections removed from the pool.")
+	fmt.Fprintf(w, "redis_pool_stale_conns_total %d\n", stats.StaleConns)
+	writeMetricHeader(w, "redis_pool_total_conns", "gauge", "Number of connections in the pool.")
+	fmt.Fprintf(w, "redis_pool_total_conns %d\n", stats.TotalConns)
+	writeMetricHeader(w, "redis_pool_idle_conns", "gauge", "Number of idle connections in the pool.")
+	fmt.Fprintf(w, "redis_pool_idle_conns %d\n", stats.IdleConns)
+}
+
+func writeMetricHeader(w *bufio.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// MetricsHandler returns the http.Handler of the metrics, nil if Config.Metrics is not enabled
+func (c Client) MetricsHandler() http.Handler {
+	if c.metrics == nil {
+		return nil
+	}
+	return c.metrics
+}
@@ -0,0 +1,292 @@
+package redis
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const defaultMigrateScanCount = 500
+
+// ConflictPolicy of the migrator for keys already in the destination
+type ConflictPolicy int
+
+const (
+	// ConflictSkip keeps the destination key
+	ConflictSkip ConflictPolicy = iota
+	// ConflictOverwrite replaces the destination key
+	ConflictOverwrite
+	// ConflictFail stops the migration with a MigrateConflictError, the keys of the
+	// batch are checked with Exists before any of them is restored
+	ConflictFail
+)
+
+// MigrateOptions for the migrator
+type MigrateOptions struct {
+	// Match pattern of the keys, default all
+	Match string
+	// ScanCount hint per Scan page, every page is copied in one batch, default 500
+	ScanCount int64
+	// Conflict policy for keys already in the destination, default ConflictSkip
+	Conflict ConflictPolicy
+	// Cursor to resume a migration from, 0 starts from the beginning
+	Cursor uint64
+	// Checkpoint is called with the cursor to resume from after every batch
+	Checkpoint func(cursor uint64) error
+	// VerifyRate is the fraction of copied keys whose type and length are compared
+	VerifyRate float64
+	// MaxKeysPerSecond limits the number of keys copied, 0 for no limit
+	MaxKeysPerSecond int
+	// MaxBytesPerSecond limits the size of the dumps copied, 0 for no limit
+	MaxBytesPerSecond int64
+	// Progress is called after every batch
+	Progress func(p MigrateProgress)
+}
+
+// MigrateProgress of a migration
+type MigrateProgress struct {
+	// Cursor to resume from
+	Cursor uint64
+	// Scanned keys so far
+	Scanned int64
+	// Copied keys so far
+	Copied int64
+	// Skipped keys so far, because they expired meanwhile or already exist in the destination
+	Skipped int64
+	// Verified keys so far
+	Verified int64
+	// Bytes of the dumps copied so far
+	Bytes int64
+}
+
+// MigrateConflictError is returned for a key already in the destination with ConflictFail
+type MigrateConflictError struct {
+	Key string
+}
+
+func (e *MigrateConflictError) Error() string {
+	return "redis: migrate key " + e.Key + " already exists"
+}
+
+// MigrateVerifyError is returned when a copied key differs from the source key
+type MigrateVerifyError struct {
+	Key    string
+	Reason string
+}
+
+func (e *MigrateVerifyError) Error() string {
+	return "redis: migrate key " + e.Key + " verification failed: " + e.Reason
+}
+
+// Migrator copies keys between two servers with Dump and Restore
+type Migrator struct {
+	src *Client
+	dst *Client
+	opt MigrateOptions
+}
+
+// NewMigrator connects to the source and the destination
+func NewMigrator(src, dst *Config, opt MigrateOptions) (*Migrator, error) {
+	if opt.ScanCount <= 0 {
+		opt.ScanCount = defaultMigrateScanCount
+	}
+	srcClient, err := NewRedisClient(src)
+	if err != nil {
+		return nil, err
+	}
+	dstClient, err := NewRedisClient(dst)
+	if err != nil {
+		_ = srcClient.Close()
+		return nil, err
+	}
+	return &Migrator{src: srcClient, dst: dstClient, opt: opt}, nil
+}
+
+// Close closes both clients
+func (m *Migrator) Close() error {
+	err := m.src.Close()
+	if dstErr := m.dst.Close(); err == nil {
+		err = dstErr
+	}
+	return err
+}
+
+// Run copies the keys from opt.Cursor until the end of the keyspace, the
+// returned progress holds the cursor to resume from after an error
+func (m *Migrator) Run(ctx context.Context) (MigrateProgress, error) {
+	progress := MigrateProgress{Cursor: m.opt.Cursor}
+	start := time.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+		keys, cursor, err := m.src.Ctx(ctx).Scan(progress.Cursor, m.opt.Match, m.opt.ScanCount).Result()
+		if err != nil {
+			return progress, err
+		}
+		if len(keys) > 0 {
+			if err := m.copy(ctx, keys, &progress); err != nil {
+				return progress, err
+			}
+		}
+		progress.Cursor = cursor
+		if m.opt.Checkpoint != nil {
+			if err := m.opt.Checkpoint(cursor); err != nil {
+				return progress, err
+			}
+		}
+		if m.opt.Progress != nil {
+			m.opt.Progress(progress)
+		}
+		if cursor == 0 {
+			return progress, nil
+		}
+		m.throttle(ctx, start, progress)
+	}
+}
+
+// throttle sleeps until the throughput is below the limits
+func (m *Migrator) throttle(ctx context.Context, start time.Time, progress MigrateProgress) {
+	var wait time.Duration
+	if m.opt.MaxKeysPerSecond > 0 {
+		wait = time.Duration(progress.Copied) * time.Second / time.Duration(m.opt.MaxKeysPerSecond)
+	}
+	if m.opt.MaxBytesPerSecond > 0 {
+		if d := time.Duration(float64(progress.Bytes) / float64(m.opt.MaxBytesPerSecond) * float64(time.Second)); d > wait {
+			wait = d
+		}
+	}
+	if d := wait - time.Since(start); d > 0 {
+		sleepCtx(ctx, d)
+	}
+}
+
+// copy dumps keys from the source and restores them in the destination
+func (m *Migrator) copy(ctx context.Context, keys []string, progress *MigrateProgress) error {
+	progress.Scanned += int64(len(keys))
+	dumpCmds := make([]*redis.StringCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	_, err := m.src.Ctx(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			dumpCmds[i] = pipe.Dump(ctx, key)
+			ttlCmds[i] = pipe.PTTL(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if m.opt.Conflict == ConflictFail {
+		if err := m.checkConflicts(ctx, keys); err != nil {
+			return err
+		}
+	}
+
+	var copied []string
+	restoreCmds := make([]*redis.StatusCmd, 0, len(keys))
+	_, err = m.dst.Ctx(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			dump, ttl := dumpCmds[i].Val(), ttlCmds[i].Val()
+			// deleted or expired meanwhile
+			if dumpCmds[i].Err() == redis.Nil || ttl == -2 {
+				progress.Skipped++
+				continue
+			}
+			if ttl < 0 {
+				ttl = 0
+			}
+			var cmd *redis.StatusCmd
+			if m.opt.Conflict == ConflictOverwrite {
+				cmd = pipe.RestoreReplace(ctx, key, ttl, dump)
+			} else {
+				cmd = pipe.Restore(ctx, key, ttl, dump)
+			}
+			copied = append(copied, key)
+			restoreCmds = append(restoreCmds, cmd)
+			progress.Bytes += int64(len(dump))
+		}
+		return nil
+	})
+	if _, ok := err.(redis.Error); err != nil && !ok {
+		return err
+	}
+
+	var verify []string
+	for i, cmd := range restoreCmds {
+		if err := cmd.Err(); err != nil {
+			if !strings.HasPrefix(err.Error(), "BUSYKEY") {
+				return err
+			}
+			if m.opt.Conflict == ConflictFail {
+				return &MigrateConflictError{Key: copied[i]}
+			}
+			progress.Skipped++
+			continue
+		}
+		progress.Copied++
+		if m.opt.VerifyRate > 0 && rand.Float64() < m.opt.VerifyRate {
+			verify = append(verify, copied[i])
+		}
+	}
+	if len(verify) == 0 {
+		return nil
+	}
+	if err := m.verify(ctx, verify); err != nil {
+		return err
+	}
+	progress.Verified += int64(len(verify))
+	return nil
+}
+
+// checkConflicts returns a MigrateConflictError for the first of keys in the destination,
+// before any key of the batch is restored, so the batch can be resumed once resolved
+func (m *Migrator) checkConflicts(ctx context.Context, keys []string) error {
+	existsCmds := make([]*redis.IntCmd, len(keys))
+	_, err := m.dst.Ctx(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			existsCmds[i] = pipe.Exists(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, cmd := range existsCmds {
+		if cmd.Val() > 0 {
+			return &MigrateConflictError{Key: keys[i]}
+		}
+	}
+	return nil
+}
+
+// verify compares the type and length of keys in both servers, unlike the
+// dumps they don't depend on the server versions
+func (m *Migrator) verify(ctx context.Context, keys []string) error {
+	src, err := m.src.Ctx(ctx).sampleKeys(keys, 0)
+	if err != nil {
+		return err
+	}
+	dst, err := m.dst.Ctx(ctx).sampleKeys(keys, 0)
+	if err != nil {
+		return err
+	}
+	dstKeys := make(map[string]BigKey, len(dst))
+	for _, k := range dst {
+		dstKeys[k.Key] = k
+	}
+	for _, k := range src {
+		d, ok := dstKeys[k.Key]
+		switch {
+		case !ok:
+			return &MigrateVerifyError{Key: k.Key, Reason: "missing"}
+		case d.Type != k.Type:
+			return &MigrateVerifyError{Key: k.Key, Reason: "type " + d.Type + " != " + k.Type}
+		case d.Length != k.Length:
+			return &MigrateVerifyError{Key: k.Key, Reason: "length differs"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const defaultPatternBatchSize = 500
+
+// errEmptyPattern keeps a zero PatternOptions from matching every key
+var errEmptyPattern = errors.New("redis: empty pattern, use \"*\" to match every key")
+
+// PatternOptions for DeleteByPattern and ExpireByPattern
+type PatternOptions struct {
+	// Match pattern of the keys, required, "*" matches every key
+	Match string
+	// Type of the keys, default any
+	Type string
+	// ScanCount hint per Scan page
+	ScanCount int64
+	// BatchSize is the number of keys per Unlink or Expire batch, default 500
+	BatchSize int
+	// Interval to wait between batches, limits the rate
+	Interval time.Duration
+	// DryRun only counts the matching keys
+	DryRun bool
+	// Progress is called after every batch
+	Progress func(p PatternProgress)
+}
+
+// PatternProgress of DeleteByPattern and ExpireByPattern
+type PatternProgress struct {
+	// Matched keys so far
+	Matched int64
+	// Affected keys so far, always 0 for a dry run
+	Affected int64
+	// Batches processed so far
+	Batches int64
+}
+
+// DeleteByPattern finds keys with Scan and unlinks them in batches
+func (c Client) DeleteByPattern(opt PatternOptions) (PatternProgress, error) {
+	return c.byPattern(opt, func(keys []string) (int64, error) {
+		return c.Unlink(keys...).Result()
+	})
+}
+
+// ExpireByPattern finds keys with Scan and sets their ttl in batches
+func (c Client) ExpireByPattern(ttl time.Duration, opt PatternOptions) (PatternProgress, error) {
+	return c.byPattern(opt, func(keys []string) (int64, error) {
+		cmds, err := c.Pipelined(func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Expire(c.getCtx(), key, ttl)
+			}
+			return nil
+		})
+		var n int64
+		for _, cmd := range cmds {
+			if cmd.(*redis.BoolCmd).Val() {
+				n++
+			}
+		}
+		return n, err
+	})
+}
+
+func (c Client) byPattern(opt PatternOptions, apply func(keys []string) (int64, error)) (PatternProgress, error) {
+	if opt.Match == "" {
+		return PatternProgress{}, errEmptyPattern
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = defaultPatternBatchSize
+	}
+	var progress PatternProgress
+	batch := make([]string, 0, opt.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if progress.Batches > 0 && opt.Interval > 0 {
+			sleepCtx(c.getCtx(), opt.Interval)
+			if err := c.getCtx().Err(); err != nil {
+				return err
+			}
+		}
+		progress.Matched += int64(len(batch))
+		if !opt.DryRun {
+			n, err := apply(batch)
+			progress.Affected += n
+			if err != nil {
+				return err
+			}
+		}
+		progress.Batches++
+		batch = batch[:0]
+		if opt.Progress != nil {
+			opt.Progress(progress)
+		}
+		return nil
+	}
+
+	err := c.ScanKeys(ScanOptions{Match: opt.Match, Count: opt.ScanCount, Type: opt.Type}, func(keys []string) error {
+		for _, key := range keys {
+			batch = append(batch, key)
+			if len(batch) >= opt.BatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return progress, err
+	}
+	return progress, flush()
+}
@@ -0,0 +1,325 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// errScanWithoutMatch is returned for a SCAN without MATCH on a prefixed client,
+// it would return keys of other namespaces
+var errScanWithoutMatch = errors.New("redis: scan without match on a prefixed client")
+
+// keyRange of the key arguments of a command, negative last counts from the end
+type keyRange struct {
+	first, last, step int
+}
+
+var (
+	keyRangeNone    = keyRange{}
+	keyRangeAll     = keyRange{1, -1, 1}
+	keyRangeTwo     = keyRange{1, 2, 1}
+	keyRangeSubcmd  = keyRange{2, 2, 1}
+	keyRangeBlocked = keyRange{1, -2, 1}
+)
+
+// keyRanges of the commands whose keys are not only the first argument
+var keyRanges = map[string]keyRange{
+	"del":         keyRangeAll,
+	"unlink":      keyRangeAll,
+	"exists":      keyRangeAll,
+	"touch":       keyRangeAll,
+	"watch":       keyRangeAll,
+	"mget":        keyRangeAll,
+	"sdiff":       keyRangeAll,
+	"sdiffstore":  keyRangeAll,
+	"sinter":      keyRangeAll,
+	"sinterstore": keyRangeAll,
+	"sunion":      keyRangeAll,
+	"sunionstore": keyRangeAll,
+	"pfcount":     keyRangeAll,
+	"pfmerge":     keyRangeAll,
+
+	"mset":   {1, -1, 2},
+	"msetnx": {1, -1, 2},
+
+	"rename":         keyRangeTwo,
+	"renamenx":       keyRangeTwo,
+	"rpoplpush":      keyRangeTwo,
+	"brpoplpush":     keyRangeTwo,
+	"lmove":          keyRangeTwo,
+	"blmove":         keyRangeTwo,
+	"smove":          keyRangeTwo,
+	"zrangestore":    keyRangeTwo,
+	"geosearchstore": keyRangeTwo,
+
+	"blpop":    keyRangeBlocked,
+	"brpop":    keyRangeBlocked,
+	"bzpopmax": keyRangeBlocked,
+	"bzpopmin": keyRangeBlocked,
+
+	"bitop":   {2, -1, 1},
+	"migrate": {3, 3, 1},
+
+	"object": keyRangeSubcmd,
+	"xgroup": keyRangeSubcmd,
+	"xinfo":  keyRangeSubcmd,
+
+	"ping": keyRangeNone, "echo": keyRangeNone, "quit": keyRangeNone, "auth": keyRangeNone,
+	"hello": keyRangeNone, "select": keyRangeNone, "swapdb": keyRangeNone,
+	"info": keyRangeNone, "config": keyRangeNone, "client": keyRangeNone, "command": keyRangeNone,
+	"dbsize": keyRangeNone, "flushdb": keyRangeNone, "flushall": keyRangeNone,
+	"save": keyRangeNone, "bgsave": keyRangeNone, "bgrewriteaof": keyRangeNone, "lastsave": keyRangeNone,
+	"shutdown": keyRangeNone, "slaveof": keyRangeNone, "replicaof": keyRangeNone, "role": keyRangeNone,
+	"time": keyRangeNone, "randomkey": keyRangeNone, "wait": keyRangeNone,
+	"readonly": keyRangeNone, "readwrite": keyRangeNone,
+	"multi": keyRangeNone, "exec": keyRangeNone, "discard": keyRangeNone, "unwatch": keyRangeNone,
+	"script": keyRangeNone, "publish": keyRangeNone, "pubsub": keyRangeNone,
+	"subscribe": keyRangeNone, "psubscribe": keyRangeNone,
+	"unsubscribe": keyRangeNone, "punsubscribe": keyRangeNone,
+	"slowlog": keyRangeNone, "latency": keyRangeNone, "acl": keyRangeNone, "module": keyRangeNone,
+}
+
+// prefixHook adds prefix to the key arguments of every command and strips it
+// from the keys returned by KEYS, SCAN, RANDOMKEY and the blocking pops
+type prefixHook struct {
+	prefix string
+}
+
+func newPrefixHook(prefix string) *prefixHook {
+	return &prefixHook{prefix: prefix}
+}
+
+func (h *prefixHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, h.prefixCmd(cmd)
+}
+
+func (h *prefixHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.stripCmd(cmd)
+	return nil
+}
+
+func (h *prefixHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	for _, cmd := range cmds {
+		if err := h.prefixCmd(cmd); err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+func (h *prefixHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.stripCmd(cmd)
+	}
+	return nil
+}
+
+func (h *prefixHook) prefixCmd(cmd redis.Cmder) error {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return nil
+	}
+	if _, ok := cmd.(*redis.ScanCmd); ok {
+		return h.prefixScan(cmd.Name(), args)
+	}
+	keyArgs(cmd, func(i int) {
+		h.prefixArg(args, i)
+	})
+	return nil
+}
+
+// keyArgs calls fn with the index of every key argument of cmd,
+// the key of SSCAN, HSCAN and ZSCAN is the first argument and SCAN has none
+func keyArgs(cmd redis.Cmder, fn func(i int)) {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return
+	}
+	key := func(i int) {
+		if i < len(args) {
+			fn(i)
+		}
+	}
+	name := cmd.Name()
+	if _, ok := cmd.(*redis.ScanCmd); ok {
+		if name != "scan" {
+			key(1)
+		}
+		return
+	}
+	switch name {
+	case "eval", "evalsha":
+		numKeys(args, 2, key)
+	case "zunion", "zinter", "zdiff":
+		numKeys(args, 1, key)
+	case "zunionstore", "zinterstore", "zdiffstore":
+		key(1)
+		numKeys(args, 2, key)
+	case "xread", "xreadgroup":
+		for i := 1; i < len(args); i++ {
+			if argEqual(args[i], "streams") {
+				n := (len(args) - i - 1) / 2
+				for j := i + 1; j <= i+n; j++ {
+					key(j)
+				}
+				break
+			}
+		}
+	case "sort", "sort_ro":
+		key(1)
+		for i := 2; i+1 < len(args); i++ {
+			if argEqual(args[i], "store") || argEqual(args[i], "by") ||
+				(argEqual(args[i], "get") && !argEqual(args[i+1], "#")) {
+				i++
+				key(i)
+			}
+		}
+	case "georadius", "georadiusbymember":
+		key(1)
+		for i := 2; i+1 < len(args); i++ {
+			if argEqual(args[i], "store") || argEqual(args[i], "storedist") {
+				i++
+				key(i)
+			}
+		}
+	case "memory", "debug", "cluster":
+		if argEqual(args[1], "usage") || argEqual(args[1], "object") || argEqual(args[1], "keyslot") {
+			key(2)
+		}
+	default:
+		r, ok := keyRanges[name]
+		if !ok {
+			r = keyRange{1, 1, 1}
+		}
+		rangeKeys(args, r, key)
+	}
+}
+
+// prefixScan prefixes the args of SCAN, SSCAN, HSCAN and ZSCAN only once,
+// ScanIterator sends the same args again for every page
+func (h *prefixHook) prefixScan(name string, args []interface{}) error {
+	if name != "scan" {
+		h.prefixArgOnce(args, 1)
+		return nil
+	}
+	for i := 2; i+1 < len(args); i++ {
+		if argEqual(args[i], "match") {
+			h.prefixArgOnce(args, i+1)
+			return nil
+		}
+	}
+	return errScanWithoutMatch
+}
+
+func rangeKeys(args []interface{}, r keyRange, key func(i int)) {
+	if r.step == 0 {
+		return
+	}
+	last := r.last
+	if last < 0 {
+		last += len(args)
+	}
+	for i := r.first; i <= last && i < len(args); i += r.step {
+		key(i)
+	}
+}
+
+func numKeys(args []interface{}, pos int, key func(i int)) {
+	if pos >= len(args) {
+		return
+	}
+	n, ok := args[pos].(int)
+	if !ok {
+		return
+	}
+	for i := pos + 1; i <= pos+n && i < len(args); i++ {
+		key(i)
+	}
+}
+
+func (h *prefixHook) prefixArg(args []interface{}, i int) {
+	if i >= len(args) {
+		return
+	}
+	switch v := args[i].(type) {
+	case string:
+		args[i] = h.prefix + v
+	case []byte:
+		args[i] = append([]byte(h.prefix), v...)
+	}
+}
+
+// prefixedArg marks an argument already prefixed, it's sent as is
+type prefixedArg string
+
+func (a prefixedArg) MarshalBinary() ([]byte, error) {
+	return []byte(a), nil
+}
+
+func (h *prefixHook) prefixArgOnce(args []interface{}, i int) {
+	if _, ok := args[i].(prefixedArg); ok {
+		return
+	}
+	h.prefixArg(args, i)
+	switch v := args[i].(type) {
+	case string:
+		args[i] = prefixedArg(v)
+	case []byte:
+		args[i] = prefixedArg(v)
+	}
+}
+
+func (h *prefixHook) strip(key string) string {
+	return strings.TrimPrefix(key, h.prefix)
+}
+
+func (h *prefixHook) stripCmd(cmd redis.Cmder) {
+	if cmd.Err() != nil {
+		return
+	}
+	switch cmd := cmd.(type) {
+	case *redis.ScanCmd:
+		if cmd.Name() != "scan" {
+			return
+		}
+		keys, cursor := cmd.Val()
+		cmd.SetVal(h.stripAll(keys), cursor)
+	case *redis.StringSliceCmd:
+		switch cmd.Name() {
+		case "keys":
+			cmd.SetVal(h.stripAll(cmd.Val()))
+		case "blpop", "brpop":
+			if val := cmd.Val(); len(val) == 2 {
+				val[0] = h.strip(val[0])
+			}
+		}
+	case *redis.StringCmd:
+		if cmd.Name() == "randomkey" {
+			cmd.SetVal(h.strip(cmd.Val()))
+		}
+	case *redis.ZWithKeyCmd:
+		if val := cmd.Val(); val != nil {
+			val.Key = h.strip(val.Key)
+		}
+	case *redis.XStreamSliceCmd:
+		val := cmd.Val()
+		for i := range val {
+			val[i].Stream = h.strip(val[i].Stream)
+		}
+	}
+}
+
+func (h *prefixHook) stripAll(keys []string) []string {
+	for i, key := range keys {
+		keys[i] = h.strip(key)
+	}
+	return keys
+}
+
+func argEqual(arg interface{}, s string) bool {
+	v, ok := arg.(string)
+	return ok && strings.EqualFold(v, s)
+}
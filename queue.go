@@ -0,0 +1,321 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultQueueMaxAttempts       = 3
+	defaultQueueConcurrency       = 1
+	defaultQueueBlockTimeout      = time.Second
+	defaultQueueHeartbeatInterval = 5 * time.Second
+)
+
+// QueueOptions for reliable queue
+type QueueOptions struct {
+	// Name of the queue, all keys are prefixed with "queue:{Name}:"
+	Name string
+	// MaxAttempts before a job is moved to the dead-letter list, default 3
+	MaxAttempts int
+	// Concurrency is the number of workers started by Run, default 1
+	Concurrency int
+	// BlockTimeout of BLMove, the worker checks for shutdown at this interval, default 1s
+	BlockTimeout time.Duration
+	// HeartbeatInterval of the workers, default 5s
+	HeartbeatInterval time.Duration
+	// DeadAfter is how long a worker may miss heartbeats before it's reaped, default 3 * HeartbeatInterval
+	DeadAfter time.Duration
+	// ReapInterval of the reaper started by Run, default DeadAfter
+	ReapInterval time.Duration
+}
+
+// Job of reliable queue
+type Job struct {
+	ID       string `json:"id"`
+	Payload  string `json:"payload"`
+	Attempts int    `json:"attempts"`
+
+	raw string
+}
+
+// QueueHandler processes a job, returning an error requeues it
+type QueueHandler func(ctx context.Context, job *Job) error
+
+// Queue is a reliable queue on lists, every worker moves jobs into its own
+// processing list with BLMove and removes them once acked
+type Queue struct {
+	client Client
+	opt    QueueOptions
+}
+
+// requeueScript moves every job of the processing list KEYS[1] back to the
+// pending list KEYS[2], or to the dead-letter list KEYS[3] once it reaches
+// ARGV[1] attempts or isn't a job envelope, and removes worker ARGV[2] from
+// the worker set KEYS[4]
+var requeueScript = redis.NewScript(`
+local max = tonumber(ARGV[1])
+local moved = 0
+while true do
+	local raw = redis.call("RPOP", KEYS[1])
+	if not raw then
+		break
+	end
+	local ok, job = pcall(cjson.decode, raw)
+	if not ok or type(job) ~= "table" then
+		redis.call("LPUSH", KEYS[3], raw)
+	else
+		job.attempts = (job.attempts or 0) + 1
+		if max > 0 and job.attempts >= max then
+			redis.call("LPUSH", KEYS[3], cjson.encode(job))
+		else
+			redis.call("LPUSH", KEYS[2], cjson.encode(job))
+		end
+	end
+	moved = moved + 1
+end
+if ARGV[2] ~= "" then
+	redis.call("ZREM", KEYS[4], ARGV[2])
+end
+return moved
+`)
+
+// nackScript removes ARGV[1] from the processing list KEYS[1] and pushes
+// ARGV[2] to the pending list KEYS[2]
+var nackScript = redis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call("LPUSH", KEYS[2], ARGV[2])
+return 1
+`)
+
+// releaseScript removes ARGV[1] from the processing list KEYS[1] and puts it
+// back at the head of the pending list KEYS[2] without an attempt
+var releaseScript = redis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call("RPUSH", KEYS[2], ARGV[1])
+return 1
+`)
+
+// NewQueue return the reliable queue
+func (c Client) NewQueue(opt QueueOptions) *Queue {
+	if opt.MaxAttempts <= 0 {
+		opt.MaxAttempts = defaultQueueMaxAttempts
+	}
+	if opt.Concurrency <= 0 {
+		opt.Concurrency = defaultQueueConcurrency
+	}
+	if opt.BlockTimeout <= 0 {
+		opt.BlockTimeout = defaultQueueBlockTimeout
+	}
+	if opt.HeartbeatInterval <= 0 {
+		opt.HeartbeatInterval = defaultQueueHeartbeatInterval
+	}
+	if opt.DeadAfter <= 0 {
+		opt.DeadAfter = 3 * opt.HeartbeatInterval
+	}
+	if opt.ReapInterval <= 0 {
+		opt.ReapInterval = opt.DeadAfter
+	}
+	return &Queue{client: Client{Client: c.Client}, opt: opt}
+}
+
+func (q *Queue) key(name string) string {
+	return "queue:{" + q.opt.Name + "}:" + name
+}
+
+// PendingKey returns the key of the pending list
+func (q *Queue) PendingKey() string {
+	return q.key("pending")
+}
+
+// DeadLetterKey returns the key of the dead-letter list
+func (q *Queue) DeadLetterKey() string {
+	return q.key("dead")
+}
+
+func (q *Queue) workersKey() string {
+	return q.key("workers")
+}
+
+func (q *Queue) processingKey(worker string) string {
+	return q.key("processing:" + worker)
+}
+
+// Push adds a job with payload to the queue and returns its id
+func (q *Queue) Push(ctx context.Context, payload string) (string, error) {
+	job := Job{ID: newJobID(), Payload: payload}
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	err = q.client.Ctx(ctx).LPush(q.PendingKey(), raw).Err()
+	if err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// Run starts the workers and the reaper and blocks until ctx is done,
+// jobs in progress are finished before Run returns
+func (q *Queue) Run(ctx context.Context, handler QueueHandler) error {
+	if handler == nil {
+		return errors.New("redis: queue handler is nil")
+	}
+
+	workers := make([]string, q.opt.Concurrency)
+	for i := range workers {
+		workers[i] = newWorkerID()
+	}
+	// register the workers before they fetch any job, so the reaper can find them
+	if err := q.heartbeat(workers); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(workers) + 1)
+	go func() {
+		defer wg.Done()
+		q.maintain(ctx, workers)
+	}()
+	for _, worker := range workers {
+		go func(worker string) {
+			defer wg.Done()
+			q.work(ctx, worker, handler)
+		}(worker)
+	}
+	wg.Wait()
+
+	// every job was acked or nacked, the workers leave nothing behind
+	for _, worker := range workers {
+		_ = requeueScript.Run(context.Background(), q.client.Client,
+			[]string{q.processingKey(worker), q.PendingKey(), q.DeadLetterKey(), q.workersKey()},
+			q.opt.MaxAttempts, worker).Err()
+	}
+	return ctx.Err()
+}
+
+func (q *Queue) work(ctx context.Context, worker string, handler QueueHandler) {
+	client := q.client.Ctx(context.Background())
+	processing := q.processingKey(worker)
+	for ctx.Err() == nil {
+		raw, err := client.BLMove(q.PendingKey(), processing, "RIGHT", "LEFT", q.opt.BlockTimeout).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			sleepCtx(ctx, q.opt.BlockTimeout)
+			continue
+		}
+		// moved while shutting down, the handler would only fail on the canceled ctx
+		if ctx.Err() != nil {
+			_ = releaseScript.Run(context.Background(), client.Client, []string{processing, q.PendingKey()}, raw).Err()
+			return
+		}
+
+		job := &Job{raw: raw}
+		if err := json.Unmarshal([]byte(raw), job); err != nil {
+			// not a job envelope, it can never succeed
+			_ = client.LRem(processing, 1, raw).Err()
+			_ = client.LPush(q.DeadLetterKey(), raw).Err()
+			continue
+		}
+		if err := handler(ctx, job); err != nil {
+			_ = q.nack(client, processing, job)
+			continue
+		}
+		_ = client.LRem(processing, 1, raw).Err()
+	}
+}
+
+func (q *Queue) nack(client *Client, processing string, job *Job) error {
+	retry := *job
+	retry.Attempts++
+	target := q.PendingKey()
+	if retry.Attempts >= q.opt.MaxAttempts {
+		target = q.DeadLetterKey()
+	}
+	raw, err := json.Marshal(retry)
+	if err != nil {
+		return err
+	}
+	return nackScript.Run(client.getCtx(), client.Client, []string{processing, target}, job.raw, raw).Err()
+}
+
+func (q *Queue) maintain(ctx context.Context, workers []string) {
+	heartbeat := time.NewTicker(q.opt.HeartbeatInterval)
+	defer heartbeat.Stop()
+	reap := time.NewTicker(q.opt.ReapInterval)
+	defer reap.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			_ = q.heartbeat(workers)
+		case <-reap.C:
+			_, _ = q.Reap(ctx)
+		}
+	}
+}
+
+func (q *Queue) heartbeat(workers []string) error {
+	now := float64(time.Now().UnixNano() / int64(time.Millisecond))
+	members := make([]*redis.Z, len(workers))
+	for i, worker := range workers {
+		members[i] = &redis.Z{Score: now, Member: worker}
+	}
+	return q.client.ZAdd(q.workersKey(), members...).Err()
+}
+
+// Reap requeues the jobs of workers that missed their heartbeats and returns
+// the number of jobs moved, it's safe to run on many instances at once
+func (q *Queue) Reap(ctx context.Context) (int64, error) {
+	client := q.client.Ctx(ctx)
+	deadline := time.Now().Add(-q.opt.DeadAfter).UnixNano() / int64(time.Millisecond)
+	dead, err := client.ZRangeByScore(q.workersKey(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(deadline, 10),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// one failing worker doesn't keep the others from being reaped
+	var moved int64
+	var firstErr error
+	for _, worker := range dead {
+		n, err := requeueScript.Run(ctx, client.Client,
+			[]string{q.processingKey(worker), q.PendingKey(), q.DeadLetterKey(), q.workersKey()},
+			q.opt.MaxAttempts, worker).Int64()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		moved += n
+	}
+	return moved, firstErr
+}
+
+func newWorkerID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), newJobID())
+}
+
+func newJobID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.FormatInt(rand.Int63(), 36)
+}
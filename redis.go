@@ -13,12 +13,46 @@ type Config struct {
 	Password string `yaml:"password"`
 	DB       int    `yaml:"db"`
 	PoolSize int    `yaml:"pool_size"`
+
+	// KeyPrefix is added to every key argument and stripped from the keys returned
+	KeyPrefix string `yaml:"key_prefix"`
+
+	// DenyCommands are rejected, e.g. "flushall" or "config set"
+	DenyCommands []string `yaml:"deny_commands"`
+	// AllowCommands if not empty are the only commands allowed
+	AllowCommands []string `yaml:"allow_commands"`
+	// DenyDangerous denies DangerousCommands
+	DenyDangerous bool `yaml:"deny_dangerous"`
+	// ReadOnly rejects every write command
+	ReadOnly bool `yaml:"read_only"`
+	// GuardOverride allows denied commands, e.g. for admin tools
+	GuardOverride GuardOverride `yaml:"-"`
+
+	// Metrics records command and pool metrics, see Client.MetricsHandler
+	Metrics bool `yaml:"metrics"`
+
+	// Tracer opens a span per command and per pipeline
+	Tracer Tracer `yaml:"-"`
+	// TraceRedactor returns the db.statement of the spans, default RedactArgs
+	TraceRedactor StatementRedactor `yaml:"-"`
+
+	// SlowLog logs slow commands and large payloads
+	SlowLog *SlowLogOptions `yaml:"slow_log"`
+
+	// Breaker fails fast while the server is degraded, see Client.Breaker
+	Breaker *BreakerOptions `yaml:"breaker"`
+
+	// AutoPipeline batches commands issued concurrently into pipelines
+	AutoPipeline *AutoPipelineOptions `yaml:"auto_pipeline"`
 }
 
 type Client struct {
 	*redis.Client
 	ctx    context.Context
 	useCtx bool
+
+	metrics *metricsHook
+	breaker *Breaker
 }
 
 func (c Client) Pipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
@@ -534,6 +568,10 @@ func (c Client) BitField(key string, args ...interface{}) *redis.IntSliceCmd {
 }
 
 func (c Client) Scan(cursor uint64, match string, count int64) *redis.ScanCmd {
+	// an explicit pattern keeps the scan inside the key prefix
+	if match == "" {
+		match = "*"
+	}
 	if c.useCtx {
 		return c.Client.Scan(c.ctx, cursor, match, count)
 	}
@@ -542,6 +580,10 @@ func (c Client) Scan(cursor uint64, match string, count int64) *redis.ScanCmd {
 }
 
 func (c Client) ScanType(cursor uint64, match string, count int64, keyType string) *redis.ScanCmd {
+	// an explicit pattern keeps the scan inside the key prefix
+	if match == "" {
+		match = "*"
+	}
 	if c.useCtx {
 		return c.Client.ScanType(c.ctx, cursor, match, count, keyType)
 	}
@@ -2262,11 +2304,17 @@ func (c Client) GeoHash(key string, members ...string) *redis.StringSliceCmd {
 }
 
 func (c Client) Ctx(ctx context.Context) *Client {
-	return &Client{
-		Client: c.Client,
-		ctx:    ctx,
-		useCtx: true,
+	client := c
+	client.ctx = ctx
+	client.useCtx = true
+	return &client
+}
+
+func (c Client) getCtx() context.Context {
+	if c.useCtx {
+		return c.ctx
 	}
+	return context.Background()
 }
 
 // NewRedisClient return the redis client
@@ -2284,5 +2332,34 @@ func NewRedisClient(conf *Config) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{Client: client, useCtx: false}, nil
+	c := &Client{Client: client, useCtx: false}
+	// metrics come first to count the commands rejected by the guard and the breaker,
+	// the rejections served by the breaker fallback are counted as breaker_fallback
+	// except for the commands of an auto pipelined batch, they count as successes
+	if conf.Metrics {
+		c.metrics = newMetricsHook(client)
+		client.AddHook(c.metrics)
+	}
+	// the guard checks every command before it's batched, not the batch
+	if guard := newGuardHook(conf); guard != nil {
+		client.AddHook(guard)
+	}
+	// batches run through the following hooks as pipelines
+	if conf.AutoPipeline != nil {
+		client.AddHook(newAutoPipelineHook(client, *conf.AutoPipeline))
+	}
+	if conf.KeyPrefix != "" {
+		client.AddHook(newPrefixHook(conf.KeyPrefix))
+	}
+	if conf.Breaker != nil {
+		c.breaker = newBreaker(*conf.Breaker)
+		client.AddHook(c.breaker)
+	}
+	if conf.Tracer != nil {
+		client.AddHook(newTracingHook(conf))
+	}
+	if conf.SlowLog != nil {
+		client.AddHook(newSlowLogHook(*conf.SlowLog))
+	}
+	return c, nil
 }
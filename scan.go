@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrStopScan stops a scan iterator without error when returned by its callback
+var ErrStopScan = errors.New("redis: stop scan")
+
+// ScanOptions for scan iterators
+type ScanOptions struct {
+	// Match pattern, default all
+	Match string
+	// Count hint per page
+	Count int64
+	// Type of the keys, only used by ScanKeys
+	Type string
+}
+
+// HashField returned by HScanFields
+type HashField struct {
+	Name  string
+	Value string
+}
+
+// scanPages walks the cursor of next, calling fn for every non-empty page
+func (c Client) scanPages(next func(cursor uint64) *redis.ScanCmd, fn func(page []string) error) error {
+	ctx := c.getCtx()
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, nextCursor, err := next(cursor).Result()
+		if err != nil {
+			return err
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				if err == ErrStopScan {
+					return nil
+				}
+				return err
+			}
+		}
+		if nextCursor == 0 {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
+
+// ScanKeys walks the keyspace with Scan, or ScanType if opt.Type is set,
+// and calls fn with every batch of keys, a key may be returned more than once
+func (c Client) ScanKeys(opt ScanOptions, fn func(keys []string) error) error {
+	return c.scanPages(func(cursor uint64) *redis.ScanCmd {
+		if opt.Type != "" {
+			return c.ScanType(cursor, opt.Match, opt.Count, opt.Type)
+		}
+		return c.Scan(cursor, opt.Match, opt.Count)
+	}, fn)
+}
+
+// SScanMembers walks the set key with SScan and calls fn with every batch of members
+func (c Client) SScanMembers(key string, opt ScanOptions, fn func(members []string) error) error {
+	return c.scanPages(func(cursor uint64) *redis.ScanCmd {
+		return c.SScan(key, cursor, opt.Match, opt.Count)
+	}, fn)
+}
+
+// HScanFields walks the hash key with HScan and calls fn with every batch of fields
+func (c Client) HScanFields(key string, opt ScanOptions, fn func(fields []HashField) error) error {
+	return c.scanPages(func(cursor uint64) *redis.ScanCmd {
+		return c.HScan(key, cursor, opt.Match, opt.Count)
+	}, func(page []string) error {
+		fields := make([]HashField, 0, len(page)/2)
+		for i := 0; i+1 < len(page); i += 2 {
+			fields = append(fields, HashField{Name: page[i], Value: page[i+1]})
+		}
+		return fn(fields)
+	})
+}
+
+// ZScanMembers walks the sorted set key with ZScan and calls fn with every batch of members
+func (c Client) ZScanMembers(key string, opt ScanOptions, fn func(members []redis.Z) error) error {
+	return c.scanPages(func(cursor uint64) *redis.ScanCmd {
+		return c.ZScan(key, cursor, opt.Match, opt.Count)
+	}, func(page []string) error {
+		members := make([]redis.Z, 0, len(page)/2)
+		for i := 0; i+1 < len(page); i += 2 {
+			score, err := strconv.ParseFloat(page[i+1], 64)
+			if err != nil {
+				return err
+			}
+			members = append(members, redis.Z{Member: page[i], Score: score})
+		}
+		return fn(members)
+	})
+}
@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultSchedulerPollInterval = time.Second
+	defaultSchedulerBatchSize    = 100
+)
+
+// SchedulerOptions for delayed jobs
+type SchedulerOptions struct {
+	// Name of the scheduler, all keys are prefixed with "scheduler:{Name}:"
+	Name string
+	// ReadyKey is the list due jobs are pushed to, default "scheduler:{Name}:ready",
+	// use Queue.PendingKey to feed a Queue
+	ReadyKey string
+	// PollInterval of Run, default 1s
+	PollInterval time.Duration
+	// BatchSize is the max number of jobs moved per poll, default 100
+	BatchSize int
+}
+
+// Scheduler stores jobs in a sorted set scored by their run-at time and moves
+// them into a ready list once they are due
+type Scheduler struct {
+	client Client
+	opt    SchedulerOptions
+}
+
+// pollScript moves at most ARGV[2] jobs due at ARGV[1] from the schedule
+// KEYS[1] into the ready list KEYS[2] as queue Job envelopes,
+// the job hashes are found next to KEYS[1] so that a key prefix applies to them
+var pollScript = redis.NewScript(`
+local prefix = string.sub(KEYS[1], 1, -string.len("schedule") - 1) .. "job:"
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, tonumber(ARGV[2]))
+for _, id in ipairs(ids) do
+	redis.call("ZREM", KEYS[1], id)
+	local key = prefix .. id
+	local payload = redis.call("HGET", key, "payload")
+	if payload then
+		redis.call("LPUSH", KEYS[2], cjson.encode({id = id, payload = payload, attempts = 0}))
+	end
+	redis.call("DEL", key)
+end
+return #ids
+`)
+
+// rescheduleScript updates the run-at time of job ARGV[1] if it's still scheduled
+var rescheduleScript = redis.NewScript(`
+if redis.call("ZSCORE", KEYS[1], ARGV[1]) == false then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[2], ARGV[1])
+redis.call("HSET", KEYS[2], "run_at", ARGV[2])
+return 1
+`)
+
+// cancelScript removes job ARGV[1] and its payload hash
+var cancelScript = redis.NewScript(`
+local removed = redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("DEL", KEYS[2])
+return removed
+`)
+
+// NewScheduler return the delayed job scheduler
+func (c Client) NewScheduler(opt SchedulerOptions) *Scheduler {
+	s := &Scheduler{client: Client{Client: c.Client}, opt: opt}
+	if s.opt.ReadyKey == "" {
+		s.opt.ReadyKey = s.key("ready")
+	}
+	if s.opt.PollInterval <= 0 {
+		s.opt.PollInterval = defaultSchedulerPollInterval
+	}
+	if s.opt.BatchSize <= 0 {
+		s.opt.BatchSize = defaultSchedulerBatchSize
+	}
+	return s
+}
+
+func (s *Scheduler) key(name string) string {
+	return "scheduler:{" + s.opt.Name + "}:" + name
+}
+
+func (s *Scheduler) scheduleKey() string {
+	return s.key("schedule")
+}
+
+func (s *Scheduler) jobKey(id string) string {
+	return s.key("job:" + id)
+}
+
+// ReadyKey returns the key of the ready list
+func (s *Scheduler) ReadyKey() string {
+	return s.opt.ReadyKey
+}
+
+// Schedule adds a job with payload which becomes ready at runAt and returns its id
+func (s *Scheduler) Schedule(ctx context.Context, payload string, runAt time.Time) (string, error) {
+	id := newJobID()
+	score := runAt.UnixNano() / int64(time.Millisecond)
+	_, err := s.client.Ctx(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.jobKey(id), "payload", payload, "run_at", score)
+		pipe.ZAdd(ctx, s.scheduleKey(), &redis.Z{Score: float64(score), Member: id})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Reschedule changes the run-at time of a job, it returns false if the job
+// is no longer scheduled
+func (s *Scheduler) Reschedule(ctx context.Context, id string, runAt time.Time) (bool, error) {
+	score := runAt.UnixNano() / int64(time.Millisecond)
+	n, err := rescheduleScript.Run(ctx, s.client.Client,
+		[]string{s.scheduleKey(), s.jobKey(id)}, id, score).Int64()
+	return n == 1, err
+}
+
+// Cancel removes a scheduled job, it returns false if the job is no longer scheduled
+func (s *Scheduler) Cancel(ctx context.Context, id string) (bool, error) {
+	n, err := cancelScript.Run(ctx, s.client.Client,
+		[]string{s.scheduleKey(), s.jobKey(id)}, id).Int64()
+	return n == 1, err
+}
+
+// Poll moves the due jobs into the ready list and returns the number of jobs moved,
+// it's atomic and safe to run on many instances at once
+func (s *Scheduler) Poll(ctx context.Context) (int64, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	return pollScript.Run(ctx, s.client.Client,
+		[]string{s.scheduleKey(), s.opt.ReadyKey},
+		strconv.FormatInt(now, 10), s.opt.BatchSize).Int64()
+}
+
+// Run polls at PollInterval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.opt.PollInterval)
+	defer ticker.Stop()
+	for {
+		// keep polling while full batches are due
+		for {
+			n, err := s.Poll(ctx)
+			if err != nil || n < int64(s.opt.BatchSize) {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+// sleepCtx waits for d or until ctx is done, check ctx.Err() after it
+func sleepCtx(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
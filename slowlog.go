@@ -0,0 +1,265 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultSlowLogMaxArgLen    = 32
+	defaultSlowLogMaxArgs      = 16
+	defaultSlowLogMaxPerSecond = 10
+)
+
+// Logger of the slow log, it has the same method as the go-redis logger
+type Logger interface {
+	Printf(ctx context.Context, format string, v ...interface{})
+}
+
+type stdLogger struct{}
+
+func (stdLogger) Printf(ctx context.Context, format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+// SlowLogOptions for slow command and large payload logging, a zero threshold is disabled
+type SlowLogOptions struct {
+	// Latency of a command or a pipeline
+	Latency time.Duration `yaml:"latency"`
+	// ArgBytes is the total size of the arguments of a command
+	ArgBytes int `yaml:"arg_bytes"`
+	// ReplyBytes is the size of the reply of a command
+	ReplyBytes int `yaml:"reply_bytes"`
+	// MaxArgLen truncates every logged argument but the keys, default 32
+	MaxArgLen int `yaml:"max_arg_len"`
+	// MaxArgs logged per command, default 16
+	MaxArgs int `yaml:"max_args"`
+	// MaxPerSecond logs at most this many lines per second, the rest are counted
+	// and reported with the next line, default 10
+	MaxPerSecond int `yaml:"max_per_second"`
+	// Logger, default the standard log package
+	Logger Logger `yaml:"-"`
+}
+
+type slowLogStartKey struct{}
+
+// slowLogHook logs slow commands and commands with large arguments or replies
+type slowLogHook struct {
+	opt SlowLogOptions
+
+	mu         sync.Mutex
+	window     time.Time
+	logged     int
+	suppressed int
+}
+
+func newSlowLogHook(opt SlowLogOptions) *slowLogHook {
+	if opt.MaxArgLen <= 0 {
+		opt.MaxArgLen = defaultSlowLogMaxArgLen
+	}
+	if opt.MaxArgs <= 0 {
+		opt.MaxArgs = defaultSlowLogMaxArgs
+	}
+	if opt.MaxPerSecond <= 0 {
+		opt.MaxPerSecond = defaultSlowLogMaxPerSecond
+	}
+	if opt.Logger == nil {
+		opt.Logger = stdLogger{}
+	}
+	return &slowLogHook{opt: opt}
+}
+
+func (h *slowLogHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, slowLogStartKey{}, time.Now()), nil
+}
+
+func (h *slowLogHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	start, ok := ctx.Value(slowLogStartKey{}).(time.Time)
+	if !ok {
+		return nil
+	}
+	h.check(ctx, cmd, time.Since(start), true)
+	return nil
+}
+
+func (h *slowLogHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, slowLogStartKey{}, time.Now()), nil
+}
+
+func (h *slowLogHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	start, ok := ctx.Value(slowLogStartKey{}).(time.Time)
+	if !ok {
+		return nil
+	}
+	elapsed := time.Since(start)
+	if h.opt.Latency > 0 && elapsed >= h.opt.Latency && h.allow() {
+		h.opt.Logger.Printf(ctx, "redis: slow pipeline of %d commands took %s%s", len(cmds), elapsed, h.suppressedNote())
+	}
+	for _, cmd := range cmds {
+		// the latency of a single command in a pipeline is unknown
+		h.check(ctx, cmd, elapsed, false)
+	}
+	return nil
+}
+
+func (h *slowLogHook) check(ctx context.Context, cmd redis.Cmder, elapsed time.Duration, checkLatency bool) {
+	var reasons []string
+	if checkLatency && h.opt.Latency > 0 && elapsed >= h.opt.Latency {
+		reasons = append(reasons, "took "+elapsed.String())
+	}
+	if h.opt.ArgBytes > 0 {
+		if n := argsSize(cmd.Args()); n >= h.opt.ArgBytes {
+			reasons = append(reasons, "args "+strconv.Itoa(n)+" bytes")
+		}
+	}
+	if h.opt.ReplyBytes > 0 {
+		if n := replySize(cmd); n >= h.opt.ReplyBytes {
+			reasons = append(reasons, "reply "+strconv.Itoa(n)+" bytes")
+		}
+	}
+	if len(reasons) == 0 || !h.allow() {
+		return
+	}
+	h.opt.Logger.Printf(ctx, "redis: %s: %s%s", strings.Join(reasons, ", "), h.format(cmd), h.suppressedNote())
+}
+
+// allow samples the log lines so that logging can't become the bottleneck
+func (h *slowLogHook) allow() bool {
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if now.Sub(h.window) >= time.Second {
+		h.window = now
+		h.logged = 0
+	}
+	if h.logged >= h.opt.MaxPerSecond {
+		h.suppressed++
+		return false
+	}
+	h.logged++
+	return true
+}
+
+func (h *slowLogHook) suppressedNote() string {
+	h.mu.Lock()
+	n := h.suppressed
+	h.suppressed = 0
+	h.mu.Unlock()
+	if n == 0 {
+		return ""
+	}
+	return " (" + strconv.Itoa(n) + " more suppressed)"
+}
+
+// format returns the command with its arguments but the keys truncated
+func (h *slowLogHook) format(cmd redis.Cmder) string {
+	args := cmd.Args()
+	keys := make(map[int]bool)
+	keyArgs(cmd, func(i int) {
+		keys[i] = true
+	})
+	var b strings.Builder
+	for i, arg := range args {
+		if i >= h.opt.MaxArgs {
+			b.WriteString(" ... (" + strconv.Itoa(len(args)-i) + " more args)")
+			break
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		s := slowLogArg(arg)
+		if !keys[i] && len(s) > h.opt.MaxArgLen {
+			s = s[:h.opt.MaxArgLen] + "...(" + strconv.Itoa(len(s)) + " bytes)"
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// slowLogArg returns arg as sent, unlike argString it prints every type
+func slowLogArg(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(arg)
+}
+
+func argsSize(args []interface{}) int {
+	var n int
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			n += len(v)
+		case []byte:
+			n += len(v)
+		default:
+			n += 8
+		}
+	}
+	return n
+}
+
+// replySize returns the approximate size of the reply of the common commands
+func replySize(cmd redis.Cmder) int {
+	var n int
+	switch cmd := cmd.(type) {
+	case *redis.StringCmd:
+		n = len(cmd.Val())
+	case *redis.StringSliceCmd:
+		for _, s := range cmd.Val() {
+			n += len(s)
+		}
+	case *redis.StringStringMapCmd:
+		for k, v := range cmd.Val() {
+			n += len(k) + len(v)
+		}
+	case *redis.SliceCmd:
+		for _, v := range cmd.Val() {
+			if s, ok := v.(string); ok {
+				n += len(s)
+			}
+		}
+	case *redis.ZSliceCmd:
+		for _, z := range cmd.Val() {
+			if s, ok := z.Member.(string); ok {
+				n += len(s) + 8
+			}
+		}
+	case *redis.ScanCmd:
+		keys, _ := cmd.Val()
+		for _, s := range keys {
+			n += len(s)
+		}
+	case *redis.XMessageSliceCmd:
+		n = xMessagesSize(cmd.Val())
+	case *redis.XStreamSliceCmd:
+		for _, stream := range cmd.Val() {
+			n += xMessagesSize(stream.Messages)
+		}
+	}
+	return n
+}
+
+func xMessagesSize(msgs []redis.XMessage) int {
+	var n int
+	for _, msg := range msgs {
+		n += len(msg.ID)
+		for k, v := range msg.Values {
+			n += len(k)
+			if s, ok := v.(string); ok {
+				n += len(s)
+			}
+		}
+	}
+	return n
+}
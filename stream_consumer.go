@@ -0,0 +1,285 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultStreamConsumerConcurrency   = 1
+	defaultStreamConsumerBlock         = time.Second
+	defaultStreamConsumerCount         = 10
+	defaultStreamConsumerMinIdle       = time.Minute
+	defaultStreamConsumerMaxDeliveries = 5
+	defaultStreamConsumerMaxIdle       = time.Hour
+)
+
+// StreamConsumerOptions for stream consumer group worker
+type StreamConsumerOptions struct {
+	Stream string
+	Group  string
+	// Consumer name in the group, default a random name per StreamConsumer
+	Consumer string
+	// StartID of the group when it's created, default "$"
+	StartID string
+	// Concurrency is the number of handler goroutines, default 1
+	Concurrency int
+	// Block of XReadGroup, the consumer checks for shutdown at this interval, default 1s
+	Block time.Duration
+	// Count of messages read per XReadGroup and XAutoClaim, default 10
+	Count int64
+	// MinIdle before a pending message of another consumer is claimed, default 1m
+	MinIdle time.Duration
+	// ClaimInterval of XAutoClaim, default MinIdle
+	ClaimInterval time.Duration
+	// MaxDeliveries before a message is moved to the dead-letter stream, default 5
+	MaxDeliveries int64
+	// DeadLetterStream, default Stream + ":dead"
+	DeadLetterStream string
+	// ConsumerMaxIdle before a consumer of the group without pending messages is deleted,
+	// e.g. the random consumer of a restarted process, default 1h
+	ConsumerMaxIdle time.Duration
+}
+
+// StreamHandler processes a message, the message is acked if it returns nil
+type StreamHandler func(ctx context.Context, msg redis.XMessage) error
+
+// StreamConsumer reads a stream as a member of a consumer group
+type StreamConsumer struct {
+	client Client
+	opt    StreamConsumerOptions
+}
+
+// NewStreamConsumer return the stream consumer
+func (c Client) NewStreamConsumer(opt StreamConsumerOptions) *StreamConsumer {
+	if opt.StartID == "" {
+		opt.StartID = "$"
+	}
+	if opt.Consumer == "" {
+		opt.Consumer = newWorkerID()
+	}
+	if opt.Concurrency <= 0 {
+		opt.Concurrency = defaultStreamConsumerConcurrency
+	}
+	if opt.Block <= 0 {
+		opt.Block = defaultStreamConsumerBlock
+	}
+	if opt.Count <= 0 {
+		opt.Count = defaultStreamConsumerCount
+	}
+	if opt.MinIdle <= 0 {
+		opt.MinIdle = defaultStreamConsumerMinIdle
+	}
+	if opt.ClaimInterval <= 0 {
+		opt.ClaimInterval = opt.MinIdle
+	}
+	if opt.MaxDeliveries <= 0 {
+		opt.MaxDeliveries = defaultStreamConsumerMaxDeliveries
+	}
+	if opt.DeadLetterStream == "" {
+		opt.DeadLetterStream = opt.Stream + ":dead"
+	}
+	if opt.ConsumerMaxIdle <= 0 {
+		opt.ConsumerMaxIdle = defaultStreamConsumerMaxIdle
+	}
+	return &StreamConsumer{client: Client{Client: c.Client}, opt: opt}
+}
+
+// CreateGroup creates the consumer group and the stream, an existing group is not an error
+func (sc *StreamConsumer) CreateGroup(ctx context.Context) error {
+	err := sc.client.Ctx(ctx).XGroupCreateMkStream(sc.opt.Stream, sc.opt.Group, sc.opt.StartID).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// Run consumes the stream until ctx is done,
+// messages already read are handled before Run returns
+func (sc *StreamConsumer) Run(ctx context.Context, handler StreamHandler) error {
+	if handler == nil {
+		return errors.New("redis: stream handler is nil")
+	}
+	if err := sc.CreateGroup(ctx); err != nil {
+		return err
+	}
+
+	msgs := make(chan redis.XMessage)
+	var fetchers sync.WaitGroup
+	fetchers.Add(2)
+	go func() {
+		defer fetchers.Done()
+		sc.read(ctx, msgs)
+	}()
+	go func() {
+		defer fetchers.Done()
+		sc.claim(ctx, msgs)
+	}()
+
+	var handlers sync.WaitGroup
+	handlers.Add(sc.opt.Concurrency)
+	for i := 0; i < sc.opt.Concurrency; i++ {
+		go func() {
+			defer handlers.Done()
+			// ack even if ctx is done, the message was handled
+			client := sc.client.Ctx(context.Background())
+			for msg := range msgs {
+				if err := handler(ctx, msg); err != nil {
+					// left pending, it's retried once claimed
+					continue
+				}
+				_ = client.XAck(sc.opt.Stream, sc.opt.Group, msg.ID).Err()
+			}
+		}()
+	}
+
+	fetchers.Wait()
+	close(msgs)
+	handlers.Wait()
+	return ctx.Err()
+}
+
+func (sc *StreamConsumer) read(ctx context.Context, msgs chan<- redis.XMessage) {
+	client := sc.client.Ctx(ctx)
+	for ctx.Err() == nil {
+		streams, err := client.XReadGroup(&redis.XReadGroupArgs{
+			Group:    sc.opt.Group,
+			Consumer: sc.opt.Consumer,
+			Streams:  []string{sc.opt.Stream, ">"},
+			Count:    sc.opt.Count,
+			Block:    sc.opt.Block,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				_ = sc.CreateGroup(ctx)
+			}
+			sleepCtx(ctx, sc.opt.Block)
+			continue
+		}
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				// read messages are delivered even on shutdown, or they would wait for MinIdle
+				msgs <- msg
+			}
+		}
+	}
+}
+
+func (sc *StreamConsumer) claim(ctx context.Context, msgs chan<- redis.XMessage) {
+	ticker := time.NewTicker(sc.opt.ClaimInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_ = sc.deadLetter(ctx)
+		_ = sc.deleteIdleConsumers(ctx)
+
+		client := sc.client.Ctx(ctx)
+		start := "0-0"
+		for ctx.Err() == nil {
+			claimed, next, err := client.XAutoClaim(&redis.XAutoClaimArgs{
+				Stream:   sc.opt.Stream,
+				Group:    sc.opt.Group,
+				Consumer: sc.opt.Consumer,
+				MinIdle:  sc.opt.MinIdle,
+				Start:    start,
+				Count:    sc.opt.Count,
+			}).Result()
+			if err != nil {
+				break
+			}
+			for _, msg := range claimed {
+				msgs <- msg
+			}
+			if next == "0-0" || next == "" {
+				break
+			}
+			start = next
+		}
+	}
+}
+
+// deadLetter moves idle pending messages delivered MaxDeliveries times to the dead-letter stream
+func (sc *StreamConsumer) deadLetter(ctx context.Context) error {
+	client := sc.client.Ctx(ctx)
+	start := "-"
+	for {
+		pending, err := client.XPendingExt(&redis.XPendingExtArgs{
+			Stream: sc.opt.Stream,
+			Group:  sc.opt.Group,
+			Idle:   sc.opt.MinIdle,
+			Start:  start,
+			End:    "+",
+			Count:  sc.opt.Count,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		for _, p := range pending {
+			if p.RetryCount < sc.opt.MaxDeliveries {
+				continue
+			}
+			if err := sc.moveToDeadLetter(client, p); err != nil {
+				return err
+			}
+		}
+		if int64(len(pending)) < sc.opt.Count {
+			return nil
+		}
+		start = "(" + pending[len(pending)-1].ID
+	}
+}
+
+// deleteIdleConsumers deletes the consumers of the group idle for ConsumerMaxIdle
+// without pending messages, they'd otherwise pile up in the group
+func (sc *StreamConsumer) deleteIdleConsumers(ctx context.Context) error {
+	client := sc.client.Ctx(ctx)
+	consumers, err := client.XInfoConsumers(sc.opt.Stream, sc.opt.Group).Result()
+	if err != nil {
+		return err
+	}
+	for _, consumer := range consumers {
+		idle := time.Duration(consumer.Idle) * time.Millisecond
+		if consumer.Name == sc.opt.Consumer || consumer.Pending > 0 || idle < sc.opt.ConsumerMaxIdle {
+			continue
+		}
+		if err := client.XGroupDelConsumer(sc.opt.Stream, sc.opt.Group, consumer.Name).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *StreamConsumer) moveToDeadLetter(client *Client, p redis.XPendingExt) error {
+	msgs, err := client.XRangeN(sc.opt.Stream, p.ID, p.ID, 1).Result()
+	if err != nil {
+		return err
+	}
+	if len(msgs) > 0 {
+		values := make(map[string]interface{}, len(msgs[0].Values)+3)
+		for k, v := range msgs[0].Values {
+			values[k] = v
+		}
+		values["_origin_id"] = p.ID
+		values["_origin_consumer"] = p.Consumer
+		values["_deliveries"] = p.RetryCount
+		err = client.XAdd(&redis.XAddArgs{Stream: sc.opt.DeadLetterStream, Values: values}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	// trimmed messages are acked too, nothing is left to deliver
+	return client.XAck(sc.opt.Stream, sc.opt.Group, p.ID).Err()
+}
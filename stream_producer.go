@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// StreamCodec encodes messages into stream fields and decodes them back
+type StreamCodec interface {
+	Encode(v interface{}) (map[string]interface{}, error)
+	Decode(values map[string]interface{}, v interface{}) error
+}
+
+// JSONStreamCodec stores the JSON encoding of a message in a single field
+type JSONStreamCodec struct {
+	// Field name, default "data"
+	Field string
+}
+
+func (c JSONStreamCodec) field() string {
+	if c.Field == "" {
+		return "data"
+	}
+	return c.Field
+}
+
+// Encode implements StreamCodec
+func (c JSONStreamCodec) Encode(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{c.field(): b}, nil
+}
+
+// Decode implements StreamCodec
+func (c JSONStreamCodec) Decode(values map[string]interface{}, v interface{}) error {
+	s, ok := values[c.field()].(string)
+	if !ok {
+		return errors.New("redis: stream message has no field " + c.field())
+	}
+	return json.Unmarshal([]byte(s), v)
+}
+
+// StreamRetention is the trimming policy applied on each add,
+// at most one of MaxLen and MaxAge should be set
+type StreamRetention struct {
+	// MaxLen trims with MAXLEN ~ MaxLen
+	MaxLen int64
+	// MaxAge trims with MINID ~ the id of now - MaxAge
+	MaxAge time.Duration
+	// Limit of entries evicted per trim, 0 for the server default
+	Limit int64
+}
+
+// StreamProducerOptions for stream producer
+type StreamProducerOptions struct {
+	Stream string
+	// Codec of messages, default JSONStreamCodec
+	Codec     StreamCodec
+	Retention StreamRetention
+}
+
+// StreamProducer adds typed messages to a stream
+type StreamProducer struct {
+	client Client
+	opt    StreamProducerOptions
+}
+
+// NewStreamProducer return the stream producer
+func (c Client) NewStreamProducer(opt StreamProducerOptions) *StreamProducer {
+	if opt.Codec == nil {
+		opt.Codec = JSONStreamCodec{}
+	}
+	return &StreamProducer{client: Client{Client: c.Client}, opt: opt}
+}
+
+// Add encodes v and adds it to the stream, applying the retention policy
+func (p *StreamProducer) Add(ctx context.Context, v interface{}) (string, error) {
+	values, err := p.opt.Codec.Encode(v)
+	if err != nil {
+		return "", err
+	}
+	a := &redis.XAddArgs{
+		Stream: p.opt.Stream,
+		Values: values,
+	}
+	r := p.opt.Retention
+	switch {
+	case r.MaxLen > 0:
+		a.MaxLen = r.MaxLen
+		a.Approx = true
+		a.Limit = r.Limit
+	case r.MaxAge > 0:
+		a.MinID = StreamIDFromTime(time.Now().Add(-r.MaxAge))
+		a.Approx = true
+		a.Limit = r.Limit
+	}
+	return p.client.Ctx(ctx).XAdd(a).Result()
+}
+
+// Trim applies the retention policy without adding a message
+func (p *StreamProducer) Trim(ctx context.Context) (int64, error) {
+	client := p.client.Ctx(ctx)
+	r := p.opt.Retention
+	switch {
+	case r.MaxLen > 0:
+		return client.XTrimMaxLenApprox(p.opt.Stream, r.MaxLen, r.Limit).Result()
+	case r.MaxAge > 0:
+		return client.XTrimMinIDApprox(p.opt.Stream, StreamIDFromTime(time.Now().Add(-r.MaxAge)), r.Limit).Result()
+	}
+	return 0, nil
+}
+
+// Decode decodes a message read from the stream into v
+func (p *StreamProducer) Decode(msg redis.XMessage, v interface{}) error {
+	return p.opt.Codec.Decode(msg.Values, v)
+}
+
+// Since returns the messages added after t, at most count if count > 0
+func (p *StreamProducer) Since(ctx context.Context, t time.Time, count int64) ([]redis.XMessage, error) {
+	client := p.client.Ctx(ctx)
+	if count > 0 {
+		return client.XRangeN(p.opt.Stream, StreamIDFromTime(t), "+", count).Result()
+	}
+	return client.XRange(p.opt.Stream, StreamIDFromTime(t), "+").Result()
+}
+
+// StreamIDFromTime returns the smallest stream id at t, usable as XRange start
+// to replay from t
+func StreamIDFromTime(t time.Time) string {
+	ms := t.UnixNano() / int64(time.Millisecond)
+	if ms < 0 {
+		ms = 0
+	}
+	return strconv.FormatInt(ms, 10) + "-0"
+}
+
+// StreamIDTime returns the time a stream id was generated at
+func StreamIDTime(id string) (time.Time, error) {
+	ms := id
+	if i := strings.IndexByte(id, '-'); i >= 0 {
+		ms = id[:i]
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n*int64(time.Millisecond)), nil
+}
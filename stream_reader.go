@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultStreamReaderBlock = time.Second
+	defaultStreamReaderCount = 100
+)
+
+// StreamReaderOptions for stream reader
+type StreamReaderOptions struct {
+	Streams []string
+	// StartID of the streams without a checkpoint, default "$"
+	StartID string
+	// Block of XRead, the reader checks for shutdown at this interval, default 1s
+	Block time.Duration
+	// Count of messages read per XRead, default 100
+	Count int64
+	// Buffer of the messages channel, the reader stops reading once it's full
+	Buffer int
+	// CheckpointKey is a hash of the last delivered id per stream, no checkpoint if empty
+	CheckpointKey string
+}
+
+// StreamMessage read from Stream
+type StreamMessage struct {
+	Stream string
+	redis.XMessage
+}
+
+// StreamReader exposes XRead as a channel without consumer groups
+type StreamReader struct {
+	client Client
+	opt    StreamReaderOptions
+
+	mu     sync.Mutex
+	lastID map[string]string
+	err    error
+}
+
+// NewStreamReader return the stream reader
+func (c Client) NewStreamReader(opt StreamReaderOptions) *StreamReader {
+	if opt.StartID == "" {
+		opt.StartID = "$"
+	}
+	if opt.Block <= 0 {
+		opt.Block = defaultStreamReaderBlock
+	}
+	if opt.Count <= 0 {
+		opt.Count = defaultStreamReaderCount
+	}
+	return &StreamReader{
+		client: Client{Client: c.Client},
+		opt:    opt,
+		lastID: make(map[string]string, len(opt.Streams)),
+	}
+}
+
+// Messages reads the streams until ctx is done or an error occurs, then closes the channel,
+// reading stops while the consumer is behind
+func (r *StreamReader) Messages(ctx context.Context) <-chan StreamMessage {
+	ch := make(chan StreamMessage, r.opt.Buffer)
+	go func() {
+		defer close(ch)
+		r.setErr(r.run(ctx, ch))
+	}()
+	return ch
+}
+
+// Err returns the error that closed the messages channel
+func (r *StreamReader) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// LastID returns the id of the last message delivered from stream
+func (r *StreamReader) LastID(stream string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastID[stream]
+}
+
+func (r *StreamReader) setErr(err error) {
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+}
+
+func (r *StreamReader) run(ctx context.Context, ch chan<- StreamMessage) error {
+	client := r.client.Ctx(ctx)
+	if err := r.resume(client); err != nil {
+		return err
+	}
+
+	for {
+		streams := make([]string, 0, 2*len(r.opt.Streams))
+		streams = append(streams, r.opt.Streams...)
+		for _, stream := range r.opt.Streams {
+			streams = append(streams, r.LastID(stream))
+		}
+		res, err := client.XRead(&redis.XReadArgs{
+			Streams: streams,
+			Count:   r.opt.Count,
+			Block:   r.opt.Block,
+		}).Result()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, stream := range res {
+			for _, msg := range stream.Messages {
+				select {
+				case ch <- StreamMessage{Stream: stream.Stream, XMessage: msg}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				r.mu.Lock()
+				r.lastID[stream.Stream] = msg.ID
+				r.mu.Unlock()
+			}
+		}
+		if err := r.checkpoint(client); err != nil {
+			return err
+		}
+	}
+}
+
+// resume loads the checkpoint and resolves "$" to the current last id,
+// so messages added between two XRead are not lost
+func (r *StreamReader) resume(client *Client) error {
+	var saved map[string]string
+	if r.opt.CheckpointKey != "" {
+		var err error
+		saved, err = client.HGetAll(r.opt.CheckpointKey).Result()
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stream := range r.opt.Streams {
+		if id := r.lastID[stream]; id != "" {
+			continue
+		}
+		if id, ok := saved[stream]; ok {
+			r.lastID[stream] = id
+			continue
+		}
+		if r.opt.StartID != "$" {
+			r.lastID[stream] = r.opt.StartID
+			continue
+		}
+		last, err := client.XRevRangeN(stream, "+", "-", 1).Result()
+		if err != nil {
+			return err
+		}
+		r.lastID[stream] = "0-0"
+		if len(last) > 0 {
+			r.lastID[stream] = last[0].ID
+		}
+	}
+	return nil
+}
+
+func (r *StreamReader) checkpoint(client *Client) error {
+	if r.opt.CheckpointKey == "" {
+		return nil
+	}
+	r.mu.Lock()
+	values := make([]interface{}, 0, 2*len(r.lastID))
+	for stream, id := range r.lastID {
+		values = append(values, stream, id)
+	}
+	r.mu.Unlock()
+	return client.HSet(r.opt.CheckpointKey, values...).Err()
+}
@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	tagKeyPrefix = "tag:"
+
+	// invalidateTagsBatch is the number of keys deleted per script call
+	invalidateTagsBatch = 500
+)
+
+// addTagsLua adds KEYS[1] to the tag sets KEYS[2..] and keeps every tag set
+// alive at least as long as its members, ARGV[1] is the member ttl in ms, 0 for none
+const addTagsLua = `
+local ttl = tonumber(ARGV[1])
+for i = 2, #KEYS do
+	local exists = redis.call("EXISTS", KEYS[i])
+	redis.call("SADD", KEYS[i], KEYS[1])
+	if ttl == 0 then
+		redis.call("PERSIST", KEYS[i])
+	elseif exists == 0 then
+		redis.call("PEXPIRE", KEYS[i], ttl)
+	else
+		local cur = redis.call("PTTL", KEYS[i])
+		if cur >= 0 and cur < ttl then
+			redis.call("PEXPIRE", KEYS[i], ttl)
+		end
+	end
+end
+`
+
+var setWithTagsScript = redis.NewScript(`
+if tonumber(ARGV[1]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[1])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+` + addTagsLua + `
+return 1
+`)
+
+var addTagsScript = redis.NewScript(addTagsLua + `
+return 1
+`)
+
+// invalidateTagsScript pops at most ARGV[1] members from the tag sets KEYS
+// and deletes them, it returns {deleted, more}
+var invalidateTagsScript = redis.NewScript(`
+local n = tonumber(ARGV[1])
+local deleted = 0
+for _, tag in ipairs(KEYS) do
+	while n > 0 do
+		local members = redis.call("SPOP", tag, n)
+		if #members == 0 then
+			break
+		end
+		deleted = deleted + redis.call("DEL", unpack(members))
+		n = n - #members
+	end
+end
+if n > 0 then
+	redis.call("DEL", unpack(KEYS))
+	return {deleted, 0}
+end
+return {deleted, 1}
+`)
+
+// SetWithTags sets key to value and adds key to the sets of tags
+func (c Client) SetWithTags(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	keys := append([]string{key}, tagKeys(tags)...)
+	return setWithTagsScript.Run(c.getCtx(), c.Client, keys, expiration.Milliseconds(), value).Err()
+}
+
+// AddTags adds an existing key to the sets of tags, expiration is the ttl of key
+func (c Client) AddTags(key string, expiration time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := append([]string{key}, tagKeys(tags)...)
+	return addTagsScript.Run(c.getCtx(), c.Client, keys, expiration.Milliseconds()).Err()
+}
+
+// InvalidateTags deletes all keys tagged with tags and the tag sets,
+// keys are deleted in batches so that huge tags don't block the server.
+// Tagged keys are not declared to the script, so it doesn't work on redis cluster
+func (c Client) InvalidateTags(tags ...string) (int64, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+	keys := tagKeys(tags)
+	var total int64
+	for {
+		res, err := invalidateTagsScript.Run(c.getCtx(), c.Client, keys, invalidateTagsBatch).Slice()
+		if err != nil {
+			return total, err
+		}
+		deleted, _ := res[0].(int64)
+		more, _ := res[1].(int64)
+		total += deleted
+		if more == 0 {
+			return total, nil
+		}
+	}
+}
+
+func tagKeys(tags []string) []string {
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = tagKeyPrefix + tag
+	}
+	return keys
+}
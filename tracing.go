@@ -0,0 +1,237 @@
+package redis
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Tracer starts spans, adapt it to your tracing library
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span of a command or a pipeline
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// StatementRedactor returns the db.statement of a command
+type StatementRedactor func(cmd redis.Cmder) string
+
+// RedactArgs keeps the command name and hides every argument, it's the default
+func RedactArgs(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) <= 1 {
+		return commandName(cmd)
+	}
+	return commandName(cmd) + " ?"
+}
+
+// RedactValues keeps the command name and its first key, e.g. "set user:1 ?"
+func RedactValues(cmd redis.Cmder) string {
+	name := commandName(cmd)
+	args := cmd.Args()
+	first := 1 + strings.Count(name, " ")
+	if len(args) <= first {
+		return name
+	}
+	s := name + " " + argString(args[first])
+	if len(args) > first+1 {
+		s += " ?"
+	}
+	return s
+}
+
+// NoRedaction keeps every argument
+func NoRedaction(cmd redis.Cmder) string {
+	args := cmd.Args()
+	ss := make([]string, len(args))
+	for i, arg := range args {
+		ss[i] = argString(arg)
+	}
+	return strings.Join(ss, " ")
+}
+
+func argString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Duration:
+		return v.String()
+	}
+	return "?"
+}
+
+type tracingSpanKey struct{}
+
+// tracingHook opens a span per command and per pipeline
+type tracingHook struct {
+	tracer   Tracer
+	redactor StatementRedactor
+	host     string
+	port     int
+	db       int
+}
+
+func newTracingHook(conf *Config) *tracingHook {
+	h := &tracingHook{tracer: conf.Tracer, redactor: conf.TraceRedactor, db: conf.DB}
+	if h.redactor == nil {
+		h.redactor = RedactArgs
+	}
+	h.host = conf.Addr
+	if host, port, err := net.SplitHostPort(conf.Addr); err == nil {
+		h.host = host
+		h.port, _ = strconv.Atoi(port)
+	}
+	return h
+}
+
+func (h *tracingHook) start(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := h.tracer.Start(ctx, name)
+	span.SetAttribute("db.system", "redis")
+	span.SetAttribute("db.redis.database_index", h.db)
+	span.SetAttribute("net.peer.name", h.host)
+	if h.port != 0 {
+		span.SetAttribute("net.peer.port", h.port)
+	}
+	return context.WithValue(ctx, tracingSpanKey{}, span), span
+}
+
+func (h *tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, span := h.start(ctx, commandName(cmd))
+	span.SetAttribute("db.statement", h.redactor(cmd))
+	return ctx, nil
+}
+
+func (h *tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	span, ok := ctx.Value(tracingSpanKey{}).(Span)
+	if !ok {
+		return nil
+	}
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		span.RecordError(err)
+	}
+	span.End()
+	return nil
+}
+
+func (h *tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, span := h.start(ctx, "pipeline")
+	statements := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		statements = append(statements, h.redactor(cmd))
+	}
+	span.SetAttribute("db.statement", strings.Join(statements, "\n"))
+	span.SetAttribute("db.redis.num_cmd", len(cmds))
+	return ctx, nil
+}
+
+func (h *tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	span, ok := ctx.Value(tracingSpanKey{}).(Span)
+	if !ok {
+		return nil
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && err != redis.Nil {
+			span.RecordError(err)
+			break
+		}
+	}
+	span.End()
+	return nil
+}
+
+// MemoryTracer keeps the spans in memory, for tests
+type MemoryTracer struct {
+	mu    sync.Mutex
+	spans []*MemorySpan
+}
+
+// MemorySpan recorded by MemoryTracer
+type MemorySpan struct {
+	Name       string
+	Parent     *MemorySpan
+	Attributes map[string]interface{}
+	Errors     []error
+	Start      time.Time
+	EndTime    time.Time
+	Ended      bool
+
+	mu sync.Mutex
+}
+
+type memorySpanKey struct{}
+
+// NewMemoryTracer return the in-memory tracer
+func NewMemoryTracer() *MemoryTracer {
+	return &MemoryTracer{}
+}
+
+// Start implements Tracer, the span in ctx is the parent
+func (t *MemoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(memorySpanKey{}).(*MemorySpan)
+	span := &MemorySpan{
+		Name:       name,
+		Parent:     parent,
+		Attributes: make(map[string]interface{}),
+		Start:      time.Now(),
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, memorySpanKey{}, span), span
+}
+
+// Spans returns the spans started so far
+func (t *MemoryTracer) Spans() []*MemorySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]*MemorySpan, len(t.spans))
+	copy(spans, t.spans)
+	return spans
+}
+
+// Reset drops the recorded spans
+func (t *MemoryTracer) Reset() {
+	t.mu.Lock()
+	t.spans = nil
+	t.mu.Unlock()
+}
+
+// SetAttribute implements Span
+func (s *MemorySpan) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	s.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+// RecordError implements Span
+func (s *MemorySpan) RecordError(err error) {
+	s.mu.Lock()
+	s.Errors = append(s.Errors, err)
+	s.mu.Unlock()
+}
+
+// End implements Span
+func (s *MemorySpan) End() {
+	s.mu.Lock()
+	s.EndTime = time.Now()
+	s.Ended = true
+	s.mu.Unlock()
+}
@@ -0,0 +1,272 @@
+package redis
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	// DefaultTTLBuckets of the remaining ttl histogram
+	DefaultTTLBuckets = []time.Duration{time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour,
+		24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+	// DefaultIdleBuckets of the idle time histogram
+	DefaultIdleBuckets = []time.Duration{time.Minute, time.Hour, 24 * time.Hour,
+		7 * 24 * time.Hour, 30 * 24 * time.Hour}
+)
+
+// TTLAuditOptions for the ttl auditor
+type TTLAuditOptions struct {
+	// Match pattern of the keys, default all
+	Match string
+	// ScanCount hint per Scan page, default 100
+	ScanCount int64
+	// SampleRate is the fraction of the keys inspected, default 1
+	SampleRate float64
+	// Delimiter of key prefixes, default ":"
+	Delimiter string
+	// PrefixDepth is the number of delimited parts of a prefix, default 1
+	PrefixDepth int
+	// Interval to wait between Scan pages, keeps the scan gentle on the server
+	Interval time.Duration
+	// TTLBuckets are the upper bounds of the ttl histogram, default DefaultTTLBuckets
+	TTLBuckets []time.Duration
+	// IdleBuckets are the upper bounds of the idle time histogram, default DefaultIdleBuckets
+	IdleBuckets []time.Duration
+	// ExpireMatch pattern of the keys without expiry which get ExpireTTL, required with ExpireTTL,
+	// "*" for every key
+	ExpireMatch string
+	// ExpireTTL is applied to the keys without expiry matching ExpireMatch when set
+	ExpireTTL time.Duration
+}
+
+// DurationHistogram counts durations by upper bound, the last count is above every bound
+type DurationHistogram struct {
+	Buckets []time.Duration
+	Counts  []int64
+}
+
+func newDurationHistogram(buckets []time.Duration) DurationHistogram {
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	return DurationHistogram{Buckets: buckets, Counts: make([]int64, len(buckets)+1)}
+}
+
+func (h *DurationHistogram) observe(d time.Duration) {
+	h.Counts[sort.Search(len(h.Buckets), func(i int) bool { return d <= h.Buckets[i] })]++
+}
+
+// TTLAuditReport of the ttl auditor
+type TTLAuditReport struct {
+	// Keys inspected
+	Keys int64
+	// NoExpiry is the number of inspected keys without ttl
+	NoExpiry int64
+	// NoExpiryByPrefix is the number of inspected keys without ttl per key prefix
+	NoExpiryByPrefix map[string]int64
+	// TTL histogram of the keys with a ttl
+	TTL DurationHistogram
+	// Idle histogram of the keys, keys are missing when the maxmemory policy is LFU
+	Idle DurationHistogram
+	// Expired is the number of keys ExpireTTL was applied to
+	Expired int64
+}
+
+// AuditTTL scans the keyspace and reports the keys without expiry and histograms
+// of the remaining ttl and idle time, optionally expiring the keys without ttl
+func (c Client) AuditTTL(opt TTLAuditOptions) (*TTLAuditReport, error) {
+	if opt.ExpireTTL > 0 && opt.ExpireMatch == "" {
+		return nil, errEmptyPattern
+	}
+	if opt.ScanCount <= 0 {
+		opt.ScanCount = defaultBigKeysScanCount
+	}
+	if opt.SampleRate <= 0 || opt.SampleRate > 1 {
+		opt.SampleRate = 1
+	}
+	if opt.Delimiter == "" {
+		opt.Delimiter = defaultBigKeysDelimiter
+	}
+	if opt.PrefixDepth <= 0 {
+		opt.PrefixDepth = 1
+	}
+	if opt.TTLBuckets == nil {
+		opt.TTLBuckets = DefaultTTLBuckets
+	}
+	if opt.IdleBuckets == nil {
+		opt.IdleBuckets = DefaultIdleBuckets
+	}
+	report := &TTLAuditReport{
+		NoExpiryByPrefix: make(map[string]int64),
+		TTL:              newDurationHistogram(opt.TTLBuckets),
+		Idle:             newDurationHistogram(opt.IdleBuckets),
+	}
+
+	first := true
+	err := c.ScanKeys(ScanOptions{Match: opt.Match, Count: opt.ScanCount}, func(keys []string) error {
+		if opt.SampleRate < 1 {
+			sampled := keys[:0:0]
+			for _, key := range keys {
+				if rand.Float64() < opt.SampleRate {
+					sampled = append(sampled, key)
+				}
+			}
+			keys = sampled
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		if !first && opt.Interval > 0 {
+			sleepCtx(c.getCtx(), opt.Interval)
+			if err := c.getCtx().Err(); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		noExpiry, err := c.auditKeys(keys, report, opt)
+		if err != nil {
+			return err
+		}
+		if opt.ExpireTTL > 0 && len(noExpiry) > 0 {
+			n, err := c.expireKeys(noExpiry, opt.ExpireTTL)
+			report.Expired += n
+			return err
+		}
+		return nil
+	})
+	return report, err
+}
+
+// auditKeys adds keys to report and returns the keys without expiry matching opt.ExpireMatch
+func (c Client) auditKeys(keys []string, report *TTLAuditReport, opt TTLAuditOptions) ([]string, error) {
+	ctx := c.getCtx()
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	idleCmds := make([]*redis.DurationCmd, len(keys))
+	_, err := c.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			ttlCmds[i] = pipe.PTTL(ctx, key)
+			idleCmds[i] = pipe.ObjectIdleTime(ctx, key)
+		}
+		return nil
+	})
+	// OBJECT IDLETIME fails with a reply error under a LFU maxmemory policy
+	if _, ok := err.(redis.Error); err != nil && !ok {
+		return nil, err
+	}
+
+	var noExpiry []string
+	for i, key := range keys {
+		ttl, err := ttlCmds[i].Result()
+		// -2 is a key deleted meanwhile
+		if err != nil || ttl == -2 {
+			continue
+		}
+		report.Keys++
+		if ttl == -1 {
+			report.NoExpiry++
+			report.NoExpiryByPrefix[keyPrefix(key, opt.Delimiter, opt.PrefixDepth)]++
+			if opt.ExpireTTL > 0 && globMatch(opt.ExpireMatch, key) {
+				noExpiry = append(noExpiry, key)
+			}
+		} else {
+			report.TTL.observe(ttl)
+		}
+		if idle, err := idleCmds[i].Result(); err == nil {
+			report.Idle.observe(idle)
+		}
+	}
+	return noExpiry, nil
+}
+
+// expireKeys sets ttl on keys and returns the number of keys which still existed
+func (c Client) expireKeys(keys []string, ttl time.Duration) (int64, error) {
+	cmds, err := c.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Expire(c.getCtx(), key, ttl)
+		}
+		return nil
+	})
+	var n int64
+	for _, cmd := range cmds {
+		if cmd.(*redis.BoolCmd).Val() {
+			n++
+		}
+	}
+	return n, err
+}
+
+// globMatch reports whether s matches the redis glob pattern
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+			if not {
+				pattern = pattern[1:]
+			}
+			match := false
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) > 1:
+					match = match || pattern[1] == s[0]
+					pattern = pattern[2:]
+				case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+					lo, hi := pattern[0], pattern[2]
+					if lo > hi {
+						lo, hi = hi, lo
+					}
+					match = match || (s[0] >= lo && s[0] <= hi)
+					pattern = pattern[3:]
+				default:
+					match = match || pattern[0] == s[0]
+					pattern = pattern[1:]
+				}
+			}
+			if len(pattern) > 0 {
+				pattern = pattern[1:]
+			}
+			if match == not {
+				return false
+			}
+			s = s[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(s) == 0
+}